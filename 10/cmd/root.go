@@ -1,40 +1,122 @@
 package cmd
 
 import (
+	"crypto/rand"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"runtime"
 
 	"gosort/internal/options"
-	"gosort/internal/run"
+	"gosort/internal/parse"
+	"gosort/internal/reader"
+	"gosort/internal/writer"
+	"gosort/pkg/gosort"
 
 	"github.com/spf13/cobra"
 )
 
 var (
-	opt options.Options
+	opt          options.Options
+	keys         []string
+	bufferSize   string
+	seed         string
+	randomSource string
+	check        string
+	quietCheck   bool
+	format       string
+	humanBase    string
+	dateLayout   string
 )
 
+// seedSize is how many bytes of --random-source (or fresh randomness) seed -R.
+const seedSize = 32
+
 var rootCmd = &cobra.Command{
-	Use:   "gosort [file]",
+	Use:   "gosort [file...]",
 	Short: "A simplified analogue of UNIX sort",
-	Args:  cobra.MaximumNArgs(1),
+	Args:  cobra.ArbitraryArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		// Disallow conflicting sort mode flags
-		modeFlags := 0
-		if opt.Numeric {
-			modeFlags++
+		opt.Date = cmd.Flags().Changed("date-sort")
+		opt.Format = options.Format(format)
+		opt.HumanBase = options.HumanBase(humanBase)
+		// conflicting sort modes, unknown formats and bases
+		if err := opt.Validate(); err != nil {
+			return err
+		}
+		if opt.Parallel < 1 {
+			return fmt.Errorf("invalid number of parallel sorts: %d", opt.Parallel)
+		}
+		switch {
+		case quietCheck:
+			opt.Check = options.CheckQuiet
+		case check == "silent":
+			opt.Check = options.CheckQuiet
+		default:
+			opt.Check = options.CheckMode(check)
+		}
+		switch opt.Check {
+		case options.CheckNone, options.CheckDiagnoseFirst, options.CheckQuiet, options.CheckAll:
+		default:
+			return fmt.Errorf("invalid argument %q for --check: choose diagnose-first, quiet or all", check)
+		}
+		if opt.Check != options.CheckNone && opt.Merge {
+			return fmt.Errorf("conflicting flags: -c and -m")
 		}
-		if opt.HumanNumeric {
-			modeFlags++
+		if opt.Check != options.CheckNone && opt.Output != "" {
+			return fmt.Errorf("conflicting flags: -c and -o")
 		}
-		if opt.Month {
-			modeFlags++
+		if opt.Check != options.CheckNone && (opt.Count || opt.Repeated || opt.UniqueOnly) {
+			return fmt.Errorf("conflicting flags: -c and --count, --repeated, --unique-only")
 		}
-		if modeFlags > 1 {
-			return fmt.Errorf("conflicting flags: choose only one of -n, -h, -M")
+		if opt.Check != options.CheckNone && len(args) > 1 {
+			return fmt.Errorf("extra operand %q not allowed with -c", args[1])
 		}
 
-		return run.Run(opt, args)
+		layout, ok := gosort.DateLayout(dateLayout)
+		if !ok {
+			return fmt.Errorf("invalid argument %q for --date-sort: not a time layout", dateLayout)
+		}
+		opt.DateLayout = layout
+
+		opt.Keys = opt.Keys[:0]
+		for _, k := range keys {
+			spec, err := gosort.ParseKey(k, opt.Format)
+			if err != nil {
+				return err
+			}
+			opt.Keys = append(opt.Keys, spec)
+		}
+
+		switch opt.Separator {
+		case `\t`:
+			opt.Separator = "\t"
+		case `\0`:
+			opt.Separator = "\x00"
+		}
+
+		if bufferSize != "" {
+			size, ok := parse.ByteSize(bufferSize)
+			if !ok {
+				return fmt.Errorf("invalid buffer size: %q", bufferSize)
+			}
+			opt.BufferSize = size
+		}
+
+		randomSeed, err := resolveSeed(cmd)
+		if err != nil {
+			return err
+		}
+		opt.RandomSeed = randomSeed
+		if opt.Debug {
+			opt.DebugLog = os.Stderr
+		}
+
+		if opt.Check != options.CheckNone {
+			return checkInput(args)
+		}
+		return sortInputs(args)
 	},
 }
 
@@ -43,23 +125,132 @@ func init() {
 	rootCmd.Flags().Bool("help", false, "")
 	_ = rootCmd.Flags().MarkHidden("help")
 
-	rootCmd.Flags().IntVarP(&opt.KeyColumn, "key", "k", 0, "sort by column number N (1-based, default: whole line; separator: tab)")
+	rootCmd.Flags().StringArrayVarP(&keys, "key", "k", nil, "sort via a key POS1[,POS2] where POS is F[.C][OPTS] (OPTS: bDdfghiMnRrTV); repeatable")
+	rootCmd.Flags().StringVar(&format, "format", string(options.FormatText), "input format: text, csv (RFC 4180, header kept on top) or jsonl (keys are JSON paths: -k .a.b:n)")
+	rootCmd.Flags().StringVarP(&opt.Separator, "field-separator", "t", "", "use SEP instead of non-blank to blank transition as the field separator")
 	rootCmd.Flags().BoolVarP(&opt.Numeric, "numeric-sort", "n", false, "compare according to numeric value")
 	rootCmd.Flags().BoolVarP(&opt.Reverse, "reverse", "r", false, "reverse the result of comparisons")
 	rootCmd.Flags().BoolVarP(&opt.Unique, "unique", "u", false, "output only the first of an equal run")
+	rootCmd.Flags().BoolVar(&opt.Count, "count", false, "like -u, but prefix lines with the number of lines sharing their key, as uniq -c")
+	rootCmd.Flags().BoolVar(&opt.Repeated, "repeated", false, "like -u, but output only keys seen more than once")
+	rootCmd.Flags().BoolVar(&opt.UniqueOnly, "unique-only", false, "output only lines whose key is seen exactly once")
+	rootCmd.Flags().IntVar(&opt.Top, "top", 0, "output only the first N lines of the result, keeping N lines in memory")
+	rootCmd.Flags().IntVar(&opt.Bottom, "bottom", 0, "output only the last N lines of the result, keeping N lines in memory")
+	rootCmd.Flags().BoolVarP(&opt.Stable, "stable", "s", false, "stabilize sort by disabling last-resort comparison")
 	rootCmd.Flags().BoolVarP(&opt.Month, "month-sort", "M", false, "compare by month name (Jan, Feb, ... Dec)")
 	rootCmd.Flags().BoolVarP(&opt.IgnoreTrailingBlanks, "ignore-trailing-blanks", "b", false, "ignore trailing blanks in key comparisons")
-	rootCmd.Flags().BoolVarP(&opt.Check, "check", "c", false, "check whether input is sorted; do not sort")
+	rootCmd.Flags().StringVarP(&check, "check", "c", "", "check whether input is sorted; do not sort (diagnose-first, quiet or all)")
+	rootCmd.Flags().Lookup("check").NoOptDefVal = string(options.CheckDiagnoseFirst)
+	rootCmd.Flags().BoolVarP(&quietCheck, "check-quiet", "C", false, "like -c, but do not report the first bad line")
+	rootCmd.Flags().BoolVarP(&opt.Version, "version-sort", "V", false, "natural sort of (version) numbers within text")
+	rootCmd.Flags().BoolVarP(&opt.GeneralNumeric, "general-numeric-sort", "g", false, "compare according to general numerical value (1e-3, inf, nan)")
+	rootCmd.Flags().BoolVarP(&opt.Random, "random-sort", "R", false, "shuffle, but group identical keys")
+	rootCmd.Flags().StringVar(&seed, "seed", "", "use STRING as the -R seed for a reproducible shuffle")
+	rootCmd.Flags().StringVar(&randomSource, "random-source", "", "get the -R seed from FILE")
+	rootCmd.Flags().BoolVarP(&opt.FoldCase, "ignore-case", "f", false, "fold lower case to upper case characters")
+	rootCmd.Flags().BoolVarP(&opt.Dictionary, "dictionary-order", "d", false, "consider only blanks and alphanumeric characters")
+	rootCmd.Flags().BoolVarP(&opt.IgnoreNonPrinting, "ignore-nonprinting", "i", false, "consider only printable characters")
+	rootCmd.Flags().StringVar(&opt.Locale, "locale", "", "collate strings by LOCALE (e.g. ru_RU, de); default: byte order")
+	rootCmd.Flags().BoolVarP(&opt.Merge, "merge", "m", false, "merge already sorted files; do not sort")
+	rootCmd.Flags().BoolVarP(&opt.ZeroTerminated, "zero-terminated", "z", false, "line delimiter is NUL, not newline")
+	rootCmd.Flags().StringVarP(&opt.Output, "output", "o", "", "write result to FILE instead of standard output; FILE may be an input")
 	rootCmd.Flags().BoolVarP(&opt.HumanNumeric, "human-numeric-sort", "h", false, "compare human readable numbers (e.g. 2K, 1M)")
+	rootCmd.Flags().StringVar(&humanBase, "human-base", string(options.HumanIEC), "multiplier of -h suffixes: iec (1K = 1024), si (1k = 1000, 1KiB = 1024) or auto (kB and k are 1000, K and KiB are 1024)")
+	rootCmd.Flags().BoolVar(&opt.Duration, "duration-sort", false, "compare durations such as 1h30m, 250ms")
+	rootCmd.Flags().StringVar(&dateLayout, "date-sort", "", "compare timestamps in LAYOUT: auto, rfc3339, syslog, iso, date or a Go time layout")
+	rootCmd.Flags().Lookup("date-sort").NoOptDefVal = "auto"
+	rootCmd.Flags().StringVar(&opt.CompressOutput, "compress-output", "", "compress the output with gzip or zstd")
+	rootCmd.Flags().StringVar(&opt.CompressTemp, "compress-temp", "", "compress temporary files with gzip or zstd")
+	rootCmd.Flags().Lookup("compress-temp").NoOptDefVal = "zstd"
+	rootCmd.Flags().BoolVar(&opt.Debug, "debug", false, "annotate the part of the line used to sort, its parsed value and why it follows the previous line")
+	rootCmd.Flags().StringVarP(&bufferSize, "buffer-size", "S", "", "use SIZE for the main memory buffer (e.g. 512K, 64M); sorted runs spill to disk beyond it")
+	rootCmd.Flags().IntVar(&opt.Parallel, "parallel", runtime.NumCPU(), "change the number of sorts run concurrently to N")
+	rootCmd.Flags().StringVarP(&opt.TempDir, "temporary-directory", "T", "", "use DIR for temporary files instead of the system default")
 
 	rootCmd.SilenceUsage = true
-	rootCmd.SilenceErrors = false
+	// errors are printed by Execute, which keeps -c disorder reports single
+	rootCmd.SilenceErrors = true
+}
+
+// resolveSeed picks the -R seed: --seed, the head of --random-source, or fresh random bytes.
+func resolveSeed(cmd *cobra.Command) ([]byte, error) {
+	switch {
+	case cmd.Flags().Changed("seed") && randomSource != "":
+		return nil, fmt.Errorf("conflicting flags: --seed and --random-source")
+	case cmd.Flags().Changed("seed"):
+		return []byte(seed), nil
+	case randomSource != "":
+		f, err := os.Open(randomSource)
+		if err != nil {
+			return nil, fmt.Errorf("open random source: %w", err)
+		}
+		defer func() { _ = f.Close() }()
+		buf := make([]byte, seedSize)
+		n, err := io.ReadFull(f, buf)
+		if err != nil && err != io.ErrUnexpectedEOF {
+			return nil, fmt.Errorf("read random source: %w", err)
+		}
+		return buf[:n], nil
+	default:
+		buf := make([]byte, seedSize)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, fmt.Errorf("generate seed: %w", err)
+		}
+		return buf, nil
+	}
+}
+
+// sortInputs sorts the named files (stdin by default) to stdout or to -o.
+func sortInputs(args []string) error {
+	inputs, err := reader.OpenInputs(args)
+	if err != nil {
+		return err
+	}
+	defer reader.CloseAll(inputs)
+
+	// Write to stdout or to a file that replaces -o only once sorting succeeds
+	out, err := writer.Open(opt.Output)
+	if err != nil {
+		return err
+	}
+	defer out.Abort()
+	rs := make([]io.Reader, len(inputs))
+	for i, in := range inputs {
+		rs[i] = in
+	}
+	if err := gosort.SortReaders(rs, out, opt); err != nil {
+		return err
+	}
+	return out.Commit()
+}
+
+// checkInput runs -c over a single file, reporting disorder GNU-style.
+func checkInput(args []string) error {
+	inputs, err := reader.OpenInputs(args)
+	if err != nil {
+		return err
+	}
+	defer reader.CloseAll(inputs)
+
+	name := "-"
+	if len(args) > 0 {
+		name = args[0]
+	}
+	return gosort.CheckAll(inputs[0], opt, func(d gosort.DisorderError) bool {
+		if opt.Check != options.CheckQuiet {
+			_, _ = fmt.Fprintf(os.Stderr, "gosort: %s:%d: disorder: %s\n", name, d.Line, d.Text)
+		}
+		return opt.Check == options.CheckAll
+	})
 }
 
 // Execute starts the program
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
-		// For -c we may return a non-zero error after printing message already.
+		// For -c the disorder has already been reported (or must stay quiet).
+		if !errors.Is(err, gosort.ErrDisorder) {
+			_, _ = fmt.Fprintln(os.Stderr, "Error:", err)
+		}
 		os.Exit(1)
 	}
 }
@@ -2,7 +2,11 @@ module gosort
 
 go 1.24.5
 
-require github.com/spf13/cobra v1.9.1
+require (
+	github.com/klauspost/compress v1.18.0
+	github.com/spf13/cobra v1.9.1
+	golang.org/x/text v0.30.0
+)
 
 require (
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
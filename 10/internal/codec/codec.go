@@ -0,0 +1,126 @@
+// Package codec detects and applies the compression of inputs, outputs and temp runs.
+package codec
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Codec names a compression format.
+type Codec string
+
+const (
+	// None means "plain bytes"
+	None Codec = ""
+	// Gzip means "RFC 1952 gzip"
+	Gzip Codec = "gzip"
+	// Bzip2 means "bzip2"; it can only be read
+	Bzip2 Codec = "bzip2"
+	// Zstd means "Zstandard"
+	Zstd Codec = "zstd"
+)
+
+var magics = []struct {
+	codec Codec
+	magic []byte
+}{
+	{Gzip, []byte{0x1f, 0x8b}},
+	{Zstd, []byte{0x28, 0xb5, 0x2f, 0xfd}},
+}
+
+// bzip2Magics start the first block of a bzip2 stream and its end, the
+// first thing after the header of an empty stream.
+var bzip2Magics = [][]byte{
+	{0x31, 0x41, 0x59, 0x26, 0x53, 0x59},
+	{0x17, 0x72, 0x45, 0x38, 0x50, 0x90},
+}
+
+// headSize is how much of an input Detect needs to see.
+const headSize = 10
+
+// Detect returns the codec whose magic bytes start head.
+func Detect(head []byte) Codec {
+	for _, m := range magics {
+		if bytes.HasPrefix(head, m.magic) {
+			return m.codec
+		}
+	}
+	if isBzip2(head) {
+		return Bzip2
+	}
+	return None
+}
+
+// isBzip2 checks the whole bzip2 header: "BZh" alone also starts plain text.
+func isBzip2(head []byte) bool {
+	if len(head) < headSize || !bytes.HasPrefix(head, []byte("BZh")) || head[3] < '1' || head[3] > '9' {
+		return false
+	}
+	for _, magic := range bzip2Magics {
+		if bytes.Equal(head[4:headSize], magic) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewReader returns a reader of the decompressed contents of r, detecting
+// the codec by its magic bytes; other data is passed through as is.
+// Closing the result releases the decoder, not r.
+func NewReader(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReaderSize(r, 64*1024)
+	head, err := br.Peek(headSize)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, err
+	}
+	switch Detect(head) {
+	case Gzip:
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("read gzip: %w", err)
+		}
+		return zr, nil
+	case Bzip2:
+		return io.NopCloser(bzip2.NewReader(br)), nil
+	case Zstd:
+		zr, err := zstd.NewReader(br, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, fmt.Errorf("read zstd: %w", err)
+		}
+		return zstdReader{zr}, nil
+	default:
+		return io.NopCloser(br), nil
+	}
+}
+
+type zstdReader struct{ *zstd.Decoder }
+
+func (z zstdReader) Close() error {
+	z.Decoder.Close()
+	return nil
+}
+
+// NewWriter returns a writer that compresses into w with c.
+// Close flushes the compressed stream but does not close w.
+func NewWriter(w io.Writer, c Codec) (io.WriteCloser, error) {
+	switch c {
+	case None:
+		return nopWriteCloser{w}, nil
+	case Gzip:
+		return gzip.NewWriter(w), nil
+	case Zstd:
+		return zstd.NewWriter(w, zstd.WithEncoderLevel(zstd.SpeedFastest), zstd.WithEncoderConcurrency(1))
+	default:
+		return nil, fmt.Errorf("cannot compress with %q: choose gzip or zstd", c)
+	}
+}
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
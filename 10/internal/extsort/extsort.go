@@ -0,0 +1,172 @@
+// Package extsort sorts inputs that do not fit in memory by spilling sorted
+// runs to temporary files and merging them back with a k-way heap merge.
+package extsort
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"gosort/internal/codec"
+	isort "gosort/internal/sort"
+)
+
+// recordOverhead is a rough per-record cost, keys included, on top of the line bytes.
+const recordOverhead = 128
+
+// maxFanIn limits how many runs are merged at once to keep open files bounded.
+const maxFanIn = 64
+
+// LineSource yields input lines one by one.
+type LineSource interface {
+	// Next returns the next line or io.EOF when the input is exhausted.
+	Next() (string, error)
+}
+
+// Config configures a Sorter.
+type Config struct {
+	// BufferSize is the memory budget in bytes; 0 means unlimited.
+	BufferSize int64
+	// TempDir is where runs are spilled; empty means the system default.
+	TempDir string
+	// Parallel is how many goroutines build and sort records; 0 or 1 means one.
+	Parallel int
+	// Compress is the codec of spilled runs; codec.None stores them as is.
+	Compress   codec.Codec
+	Compare    func(a, b isort.Record) int
+	MakeRecord func(line string) isort.Record
+}
+
+// Sorter sorts lines within a memory budget.
+type Sorter struct {
+	cfg Config
+	dir string
+}
+
+// New creates a Sorter.
+func New(cfg Config) *Sorter {
+	return &Sorter{cfg: cfg}
+}
+
+// Sort reads every line from src and passes the records to emit in sorted order.
+func (s *Sorter) Sort(src LineSource, emit func(isort.Record) error) error {
+	defer s.cleanup()
+
+	var (
+		runs  []string
+		lines []string
+		used  int64
+	)
+	for {
+		line, err := src.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		lines = append(lines, line)
+		used += int64(len(line)) + recordOverhead
+		if s.cfg.BufferSize > 0 && used >= s.cfg.BufferSize {
+			path, err := s.spill(s.sortChunk(s.buildChunk(lines)))
+			if err != nil {
+				return err
+			}
+			runs = append(runs, path)
+			lines = lines[:0]
+			used = 0
+		}
+	}
+
+	chunk := s.sortChunk(s.buildChunk(lines))
+	if len(runs) == 0 {
+		for _, rec := range chunk {
+			if err := emit(rec); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	runs, err := s.reduce(runs)
+	if err != nil {
+		return err
+	}
+	// the last chunk is still in memory, so merge it without spilling
+	return s.mergeRuns(runs, &sliceSource{records: chunk}, emit)
+}
+
+func (s *Sorter) spill(records []isort.Record) (string, error) {
+	if s.dir == "" {
+		dir, err := os.MkdirTemp(s.cfg.TempDir, "gosort-")
+		if err != nil {
+			return "", fmt.Errorf("create temp dir: %w", err)
+		}
+		s.dir = dir
+	}
+	return writeRun(s.dir, s.cfg.Compress, records)
+}
+
+// reduce merges runs in batches until at most maxFanIn-1 remain,
+// leaving room for the in-memory chunk in the final merge.
+func (s *Sorter) reduce(runs []string) ([]string, error) {
+	for len(runs) >= maxFanIn {
+		var next []string
+		for start := 0; start < len(runs); start += maxFanIn {
+			end := min(start+maxFanIn, len(runs))
+			path, err := s.mergeToRun(runs[start:end])
+			if err != nil {
+				return nil, err
+			}
+			next = append(next, path)
+		}
+		runs = next
+	}
+	return runs, nil
+}
+
+func (s *Sorter) mergeToRun(runs []string) (string, error) {
+	rw, err := newRunWriter(s.dir, s.cfg.Compress)
+	if err != nil {
+		return "", err
+	}
+	if err := s.mergeRuns(runs, nil, rw.write); err != nil {
+		_ = rw.close()
+		return "", err
+	}
+	return rw.name(), rw.close()
+}
+
+// mergeRuns merges run files, plus an optional in-memory tail, into emit.
+// Run files are removed once merged.
+func (s *Sorter) mergeRuns(runs []string, tail Source, emit func(isort.Record) error) error {
+	sources := make([]Source, 0, len(runs)+1)
+	defer func() {
+		for _, src := range sources {
+			if rs, ok := src.(*runSource); ok {
+				_ = rs.Close()
+			}
+		}
+		for _, path := range runs {
+			_ = os.Remove(path)
+		}
+	}()
+	for _, path := range runs {
+		rs, err := openRun(path, s.cfg.Compress, s.cfg.MakeRecord)
+		if err != nil {
+			return err
+		}
+		sources = append(sources, rs)
+	}
+	if tail != nil {
+		sources = append(sources, tail)
+	}
+	return Merge(sources, s.cfg.Compare, emit)
+}
+
+func (s *Sorter) cleanup() {
+	if s.dir != "" {
+		_ = os.RemoveAll(s.dir)
+		s.dir = ""
+	}
+}
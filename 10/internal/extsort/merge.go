@@ -0,0 +1,137 @@
+package extsort
+
+import (
+	"container/heap"
+	"io"
+
+	isort "gosort/internal/sort"
+)
+
+// Source yields records in sorted order.
+type Source interface {
+	// Next returns the next record or io.EOF when the source is exhausted.
+	Next() (isort.Record, error)
+}
+
+type mergeItem struct {
+	rec isort.Record
+	src int
+}
+
+// mergeHeap orders the heads of all sources; ties go to the lower source index,
+// so merging preserves the order in which the sources were produced.
+type mergeHeap struct {
+	items   []mergeItem
+	compare func(a, b isort.Record) int
+}
+
+func (h *mergeHeap) Len() int { return len(h.items) }
+
+func (h *mergeHeap) Less(i, j int) bool {
+	if c := h.compare(h.items[i].rec, h.items[j].rec); c != 0 {
+		return c < 0
+	}
+	return h.items[i].src < h.items[j].src
+}
+
+func (h *mergeHeap) Swap(i, j int) { h.items[i], h.items[j] = h.items[j], h.items[i] }
+
+func (h *mergeHeap) Push(x any) { h.items = append(h.items, x.(mergeItem)) }
+
+func (h *mergeHeap) Pop() any {
+	n := len(h.items)
+	it := h.items[n-1]
+	h.items = h.items[:n-1]
+	return it
+}
+
+// Merge performs a k-way merge of sorted sources and passes every record to emit.
+func Merge(sources []Source, compare func(a, b isort.Record) int, emit func(isort.Record) error) error {
+	h := &mergeHeap{
+		items:   make([]mergeItem, 0, len(sources)),
+		compare: compare,
+	}
+	for i, src := range sources {
+		rec, err := src.Next()
+		if err == io.EOF {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		h.items = append(h.items, mergeItem{rec: rec, src: i})
+	}
+	heap.Init(h)
+
+	for h.Len() > 0 {
+		top := h.items[0]
+		if err := emit(top.rec); err != nil {
+			return err
+		}
+		rec, err := sources[top.src].Next()
+		if err == io.EOF {
+			heap.Pop(h)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		h.items[0].rec = rec
+		heap.Fix(h, 0)
+	}
+	return nil
+}
+
+// sliceSource serves records from an already sorted slice.
+type sliceSource struct {
+	records []isort.Record
+}
+
+func (s *sliceSource) Next() (isort.Record, error) {
+	if len(s.records) == 0 {
+		return isort.Record{}, io.EOF
+	}
+	rec := s.records[0]
+	s.records = s.records[1:]
+	return rec, nil
+}
+
+// recordSource builds records from lines that are already in sorted order.
+type recordSource struct {
+	lines      LineSource
+	makeRecord func(line string) isort.Record
+}
+
+// Records wraps a sorted line stream into a Source.
+func Records(lines LineSource, makeRecord func(line string) isort.Record) Source {
+	return &recordSource{lines: lines, makeRecord: makeRecord}
+}
+
+func (s *recordSource) Next() (isort.Record, error) {
+	line, err := s.lines.Next()
+	if err != nil {
+		return isort.Record{}, err
+	}
+	return s.makeRecord(line), nil
+}
+
+// concatSource reads line sources one after another.
+type concatSource struct {
+	sources []LineSource
+}
+
+// Concat joins line sources into one.
+func Concat(sources ...LineSource) LineSource {
+	return &concatSource{sources: sources}
+}
+
+func (s *concatSource) Next() (string, error) {
+	for len(s.sources) > 0 {
+		line, err := s.sources[0].Next()
+		if err != io.EOF {
+			return line, err
+		}
+		s.sources = s.sources[1:]
+	}
+	return "", io.EOF
+}
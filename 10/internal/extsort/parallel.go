@@ -0,0 +1,76 @@
+package extsort
+
+import (
+	"sort"
+	"sync"
+
+	isort "gosort/internal/sort"
+)
+
+// minPartition is the smallest number of records worth handing to a goroutine.
+const minPartition = 4096
+
+// partitions splits [0, n) into contiguous ranges, one per worker.
+func (s *Sorter) partitions(n int) [][2]int {
+	workers := min(max(s.cfg.Parallel, 1), max(n/minPartition, 1))
+	parts := make([][2]int, 0, workers)
+	for w := 0; w < workers; w++ {
+		parts = append(parts, [2]int{n * w / workers, n * (w + 1) / workers})
+	}
+	return parts
+}
+
+// forEachPartition runs fn over every partition concurrently.
+func forEachPartition(parts [][2]int, fn func(lo, hi int)) {
+	if len(parts) == 1 {
+		fn(parts[0][0], parts[0][1])
+		return
+	}
+	var wg sync.WaitGroup
+	for _, p := range parts {
+		wg.Add(1)
+		go func(lo, hi int) {
+			defer wg.Done()
+			fn(lo, hi)
+		}(p[0], p[1])
+	}
+	wg.Wait()
+}
+
+// buildChunk makes a record for every line, parsing keys concurrently.
+func (s *Sorter) buildChunk(lines []string) []isort.Record {
+	records := make([]isort.Record, len(lines))
+	forEachPartition(s.partitions(len(lines)), func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			records[i] = s.cfg.MakeRecord(lines[i])
+		}
+	})
+	return records
+}
+
+// sortChunk sorts partitions concurrently and merges them. The merge prefers
+// earlier partitions on ties, so the result equals a single stable sort.
+func (s *Sorter) sortChunk(chunk []isort.Record) []isort.Record {
+	parts := s.partitions(len(chunk))
+	forEachPartition(parts, func(lo, hi int) {
+		part := chunk[lo:hi]
+		sort.SliceStable(part, func(i, j int) bool {
+			return s.cfg.Compare(part[i], part[j]) < 0
+		})
+	})
+	if len(parts) == 1 {
+		return chunk
+	}
+
+	sources := make([]Source, len(parts))
+	for i, p := range parts {
+		sources[i] = &sliceSource{records: chunk[p[0]:p[1]]}
+	}
+	out := make([]isort.Record, 0, len(chunk))
+	// sliceSource never fails and the emitter does not either
+	_ = Merge(sources, s.cfg.Compare, func(rec isort.Record) error {
+		out = append(out, rec)
+		return nil
+	})
+	return out
+}
@@ -0,0 +1,125 @@
+package extsort
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+
+	"gosort/internal/codec"
+	isort "gosort/internal/sort"
+)
+
+// runWriter writes records into a new run file. Each line is stored as a uvarint
+// length followed by the raw bytes, so lines may contain any byte, including newlines.
+// The whole file may be compressed with the codec of the Sorter.
+type runWriter struct {
+	f      *os.File
+	z      io.WriteCloser
+	w      *bufio.Writer
+	lenBuf [binary.MaxVarintLen64]byte
+}
+
+func newRunWriter(dir string, c codec.Codec) (*runWriter, error) {
+	f, err := os.CreateTemp(dir, "run-*")
+	if err != nil {
+		return nil, fmt.Errorf("create temp file: %w", err)
+	}
+	z, err := codec.NewWriter(f, c)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return &runWriter{f: f, z: z, w: bufio.NewWriterSize(z, 64*1024)}, nil
+}
+
+func (rw *runWriter) write(rec isort.Record) error {
+	n := binary.PutUvarint(rw.lenBuf[:], uint64(len(rec.Line)))
+	if _, err := rw.w.Write(rw.lenBuf[:n]); err != nil {
+		return fmt.Errorf("write temp file: %w", err)
+	}
+	if _, err := rw.w.WriteString(rec.Line); err != nil {
+		return fmt.Errorf("write temp file: %w", err)
+	}
+	return nil
+}
+
+func (rw *runWriter) name() string { return rw.f.Name() }
+
+func (rw *runWriter) close() error {
+	if err := rw.w.Flush(); err != nil {
+		_ = rw.f.Close()
+		return fmt.Errorf("write temp file: %w", err)
+	}
+	if err := rw.z.Close(); err != nil {
+		_ = rw.f.Close()
+		return fmt.Errorf("write temp file: %w", err)
+	}
+	if err := rw.f.Close(); err != nil {
+		return fmt.Errorf("close temp file: %w", err)
+	}
+	return nil
+}
+
+func writeRun(dir string, c codec.Codec, records []isort.Record) (string, error) {
+	rw, err := newRunWriter(dir, c)
+	if err != nil {
+		return "", err
+	}
+	for _, rec := range records {
+		if err := rw.write(rec); err != nil {
+			_ = rw.close()
+			return "", err
+		}
+	}
+	return rw.name(), rw.close()
+}
+
+// runSource reads a run file back, rebuilding records with makeRecord.
+type runSource struct {
+	f          *os.File
+	z          io.ReadCloser
+	rd         *bufio.Reader
+	makeRecord func(line string) isort.Record
+}
+
+func openRun(path string, c codec.Codec, makeRecord func(line string) isort.Record) (*runSource, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open temp file: %w", err)
+	}
+	// plain runs are never sniffed: a length byte could look like a magic number
+	z := io.NopCloser(f)
+	if c != codec.None {
+		z, err = codec.NewReader(f)
+	}
+	if err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("read temp file: %w", err)
+	}
+	return &runSource{
+		f:          f,
+		z:          z,
+		rd:         bufio.NewReaderSize(z, 64*1024),
+		makeRecord: makeRecord,
+	}, nil
+}
+
+func (s *runSource) Next() (isort.Record, error) {
+	n, err := binary.ReadUvarint(s.rd)
+	if err != nil {
+		// io.EOF is passed through as is
+		return isort.Record{}, err
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(s.rd, buf); err != nil {
+		return isort.Record{}, fmt.Errorf("read temp file: %w", err)
+	}
+	return s.makeRecord(string(buf)), nil
+}
+
+func (s *runSource) Close() error {
+	_ = s.z.Close()
+	return s.f.Close()
+}
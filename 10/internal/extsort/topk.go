@@ -0,0 +1,128 @@
+package extsort
+
+import (
+	"container/heap"
+	"io"
+	"sort"
+
+	isort "gosort/internal/sort"
+)
+
+// TopConfig configures Top.
+type TopConfig struct {
+	// N is how many records to keep.
+	N int
+	// Bottom keeps the last N records of the sorted order instead of the first N.
+	Bottom bool
+	// Same, if set, keeps one record per run of equal keys, as -u does.
+	Same       func(a, b isort.Record) bool
+	Compare    func(a, b isort.Record) int
+	MakeRecord func(line string) isort.Record
+}
+
+type topItem struct {
+	rec isort.Record
+	seq int // input position; breaks ties like a stable sort does
+}
+
+// topHeap keeps the records that are candidates for the output. Its root is
+// the worst of them: the greatest for the top, the least for the bottom.
+type topHeap struct {
+	items   []topItem
+	compare func(a, b isort.Record) int
+	bottom  bool
+}
+
+// before orders items as the sorted output would.
+func (h *topHeap) before(a, b topItem) bool {
+	if c := h.compare(a.rec, b.rec); c != 0 {
+		return c < 0
+	}
+	return a.seq < b.seq
+}
+
+func (h *topHeap) Len() int { return len(h.items) }
+
+func (h *topHeap) Less(i, j int) bool {
+	if h.bottom {
+		return h.before(h.items[i], h.items[j])
+	}
+	return h.before(h.items[j], h.items[i])
+}
+
+func (h *topHeap) Swap(i, j int) { h.items[i], h.items[j] = h.items[j], h.items[i] }
+
+func (h *topHeap) Push(x any) { h.items = append(h.items, x.(topItem)) }
+
+func (h *topHeap) Pop() any {
+	n := len(h.items)
+	it := h.items[n-1]
+	h.items = h.items[:n-1]
+	return it
+}
+
+// Top streams src once and passes to emit, in sorted order, the first (or with
+// cfg.Bottom the last) cfg.N records that a full sort would output.
+// Only N records are held in memory; with cfg.Same every new record is also
+// matched against them, which costs O(N) per line.
+func Top(src LineSource, cfg TopConfig, emit func(isort.Record) error) error {
+	if cfg.N <= 0 {
+		return nil
+	}
+	h := &topHeap{compare: cfg.Compare, bottom: cfg.Bottom}
+	for seq := 0; ; seq++ {
+		line, err := src.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		it := topItem{rec: cfg.MakeRecord(line), seq: seq}
+
+		if cfg.Same != nil {
+			// -u prints the first record of an equal run, so keep the earliest one
+			if i := h.find(it.rec, cfg.Same); i >= 0 {
+				if h.before(it, h.items[i]) {
+					h.items[i] = it
+					heap.Fix(h, i)
+				}
+				continue
+			}
+		}
+
+		switch {
+		case h.Len() < cfg.N:
+			heap.Push(h, it)
+		case h.better(it, h.items[0]):
+			h.items[0] = it
+			heap.Fix(h, 0)
+		}
+	}
+
+	sort.Slice(h.items, func(i, j int) bool { return h.before(h.items[i], h.items[j]) })
+	for _, it := range h.items {
+		if err := emit(it.rec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// better reports whether a belongs in the output rather than b.
+func (h *topHeap) better(a, b topItem) bool {
+	if h.bottom {
+		return h.before(b, a)
+	}
+	return h.before(a, b)
+}
+
+// find returns the index of a kept record with the same key as rec, or -1.
+func (h *topHeap) find(rec isort.Record, same func(a, b isort.Record) bool) int {
+	for i, it := range h.items {
+		if same(it.rec, rec) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,202 @@
+package options
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// KeySpec is a POSIX key definition: -k POS1[,POS2] where POS is F[.C][OPTS].
+// With --format=jsonl it is a JSON path instead: -k PATH[:OPTS].
+type KeySpec struct {
+	Path       string // JSON path such as .request.duration; jsonl only
+	StartField int    // 1-based; 0 means the whole line
+	StartChar  int    // 1-based offset within the start field
+	EndField   int    // 1-based; 0 means end of line
+	EndChar    int    // 1-based offset within the end field; 0 means end of field
+
+	Numeric              bool // n
+	HumanNumeric         bool // h
+	Month                bool // M
+	Version              bool // V
+	GeneralNumeric       bool // g
+	Random               bool // R
+	Duration             bool // D
+	Date                 bool // T
+	Reverse              bool // r
+	IgnoreLeadingBlanks  bool // b
+	IgnoreTrailingBlanks bool // -b, which has no per-key letter
+	FoldCase             bool // f
+	Dictionary           bool // d
+	IgnoreNonPrinting    bool // i
+}
+
+// HasModifiers reports whether the key sets any ordering options of its own.
+// Keys without modifiers inherit the global ones.
+func (k KeySpec) HasModifiers() bool {
+	return k.Numeric || k.HumanNumeric || k.Month || k.Version || k.GeneralNumeric || k.Random || k.Duration || k.Date ||
+		k.Reverse || k.IgnoreLeadingBlanks || k.IgnoreTrailingBlanks ||
+		k.FoldCase || k.Dictionary || k.IgnoreNonPrinting
+}
+
+// Inherit copies the global ordering options into a key without modifiers.
+func (k KeySpec) Inherit(opt Options) KeySpec {
+	if k.HasModifiers() {
+		return k
+	}
+	k.Numeric = opt.Numeric
+	k.HumanNumeric = opt.HumanNumeric
+	k.Month = opt.Month
+	k.Version = opt.Version
+	k.GeneralNumeric = opt.GeneralNumeric
+	k.Random = opt.Random
+	k.Duration = opt.Duration
+	k.Date = opt.Date
+	k.Reverse = opt.Reverse
+	k.IgnoreTrailingBlanks = opt.IgnoreTrailingBlanks
+	k.FoldCase = opt.FoldCase
+	k.Dictionary = opt.Dictionary
+	k.IgnoreNonPrinting = opt.IgnoreNonPrinting
+	return k
+}
+
+// ParseKey parses a key definition such as "2", "2.3,2.5" or "3,3nr".
+func ParseKey(s string) (KeySpec, error) {
+	var k KeySpec
+	pos1, pos2, hasEnd := strings.Cut(s, ",")
+
+	var err error
+	k.StartField, k.StartChar, err = parsePos(pos1, &k)
+	if err != nil {
+		return KeySpec{}, fmt.Errorf("invalid key %q: %w", s, err)
+	}
+	if k.StartField == 0 {
+		return KeySpec{}, fmt.Errorf("invalid key %q: field number is zero", s)
+	}
+	if k.StartChar == 0 {
+		k.StartChar = 1
+	}
+
+	if hasEnd {
+		k.EndField, k.EndChar, err = parsePos(pos2, &k)
+		if err != nil {
+			return KeySpec{}, fmt.Errorf("invalid key %q: %w", s, err)
+		}
+		if k.EndField == 0 {
+			return KeySpec{}, fmt.Errorf("invalid key %q: field number is zero", s)
+		}
+	}
+
+	if err := k.Validate(); err != nil {
+		return KeySpec{}, fmt.Errorf("invalid key %q: %w", s, err)
+	}
+	return k, nil
+}
+
+// ParseJSONKey parses a JSON Lines key such as ".request.duration:n" or ".items[0].id".
+func ParseJSONKey(s string) (KeySpec, error) {
+	path, mods := s, ""
+	if i := strings.LastIndexByte(s, ':'); i >= 0 {
+		path, mods = s[:i], s[i+1:]
+	}
+	if !strings.HasPrefix(path, ".") {
+		return KeySpec{}, fmt.Errorf("invalid key %q: JSON path must start with '.'", s)
+	}
+	k := KeySpec{Path: path}
+	if err := parseModifiers(mods, &k); err != nil {
+		return KeySpec{}, fmt.Errorf("invalid key %q: %w", s, err)
+	}
+	if err := k.Validate(); err != nil {
+		return KeySpec{}, fmt.Errorf("invalid key %q: %w", s, err)
+	}
+	return k, nil
+}
+
+// Validate reports negative positions and conflicting modifiers.
+func (k KeySpec) Validate() error {
+	if k.StartField < 0 || k.StartChar < 0 || k.EndField < 0 || k.EndChar < 0 {
+		return fmt.Errorf("negative position")
+	}
+	modes := 0
+	for _, set := range []bool{k.Numeric, k.HumanNumeric, k.Month, k.Version, k.GeneralNumeric, k.Random, k.Duration, k.Date} {
+		if set {
+			modes++
+		}
+	}
+	if modes > 1 {
+		return fmt.Errorf("conflicting modifiers, choose only one of n, h, M, V, g, R, D, T")
+	}
+	return nil
+}
+
+// parsePos parses F[.C][OPTS] and records the modifiers in k.
+func parsePos(s string, k *KeySpec) (field, char int, err error) {
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	if i == 0 {
+		return 0, 0, fmt.Errorf("missing field number")
+	}
+	field, err = strconv.Atoi(s[:i])
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if i < len(s) && s[i] == '.' {
+		j := i + 1
+		for j < len(s) && s[j] >= '0' && s[j] <= '9' {
+			j++
+		}
+		if j == i+1 {
+			return 0, 0, fmt.Errorf("missing character offset")
+		}
+		char, err = strconv.Atoi(s[i+1 : j])
+		if err != nil {
+			return 0, 0, err
+		}
+		i = j
+	}
+
+	if err := parseModifiers(s[i:], k); err != nil {
+		return 0, 0, err
+	}
+	return field, char, nil
+}
+
+// parseModifiers records ordering options such as "nr" in k.
+func parseModifiers(s string, k *KeySpec) error {
+	for _, c := range s {
+		switch c {
+		case 'n':
+			k.Numeric = true
+		case 'h':
+			k.HumanNumeric = true
+		case 'M':
+			k.Month = true
+		case 'V':
+			k.Version = true
+		case 'g':
+			k.GeneralNumeric = true
+		case 'R':
+			k.Random = true
+		case 'D':
+			k.Duration = true
+		case 'T':
+			k.Date = true
+		case 'r':
+			k.Reverse = true
+		case 'b':
+			k.IgnoreLeadingBlanks = true
+		case 'f':
+			k.FoldCase = true
+		case 'd':
+			k.Dictionary = true
+		case 'i':
+			k.IgnoreNonPrinting = true
+		default:
+			return fmt.Errorf("unknown modifier %q", c)
+		}
+	}
+	return nil
+}
@@ -1,13 +1,137 @@
 package options
 
+import (
+	"fmt"
+	"io"
+)
+
 // Options serves as a struct for config flags
 type Options struct {
-	KeyColumn            int  // 1-based; 0 means whole line
-	Numeric              bool // -n
-	Reverse              bool // -r
-	Unique               bool // -u
-	Month                bool // -M
-	IgnoreTrailingBlanks bool // -b (trailing)
-	Check                bool // -c
-	HumanNumeric         bool // -h
+	Keys                 []KeySpec // -k, in priority order; empty means whole line
+	Separator            string    // -t; empty means runs of blanks
+	Format               Format    // --format
+	Numeric              bool      // -n
+	Reverse              bool      // -r
+	Unique               bool      // -u
+	Count                bool      // --count; prefix unique lines with their number
+	Repeated             bool      // --repeated; print only keys seen more than once
+	UniqueOnly           bool      // --unique-only; print only keys seen exactly once
+	Top                  int       // --top; print only the first N lines; 0 means all
+	Bottom               int       // --bottom; print only the last N lines; 0 means all
+	Stable               bool      // -s
+	Month                bool      // -M
+	Version              bool      // -V
+	GeneralNumeric       bool      // -g
+	Random               bool      // -R
+	RandomSeed           []byte    // --seed or --random-source; keys the -R hash
+	Duration             bool      // --duration-sort
+	Date                 bool      // --date-sort
+	DateLayout           string    // --date-sort=LAYOUT; empty means auto-detect
+	IgnoreTrailingBlanks bool      // -b (trailing)
+	Check                CheckMode // -c, -C, --check
+	Merge                bool      // -m
+	ZeroTerminated       bool      // -z
+	Output               string    // -o; empty means stdout
+	Debug                bool      // --debug; annotate output lines with their keys
+	DebugLog             io.Writer // --debug; gets the notes on the whole run; nil drops them
+	CompressOutput       string    // --compress-output: gzip or zstd; empty means plain
+	CompressTemp         string    // --compress-temp: gzip or zstd; empty means plain
+	HumanNumeric         bool      // -h
+	HumanBase            HumanBase // --human-base
+	FoldCase             bool      // -f
+	Dictionary           bool      // -d
+	IgnoreNonPrinting    bool      // -i
+	Locale               string    // --locale; empty means byte order
+	BufferSize           int64     // -S, in bytes; 0 means unlimited
+	TempDir              string    // -T; empty means the system default
+	Parallel             int       // --parallel; number of goroutines sorting at once
+}
+
+// Validate reports conflicting ordering modes and unknown enum values.
+func (o Options) Validate() error {
+	modes := 0
+	for _, set := range []bool{o.Numeric, o.HumanNumeric, o.Month, o.Version, o.GeneralNumeric, o.Random, o.Duration, o.Date} {
+		if set {
+			modes++
+		}
+	}
+	if modes > 1 {
+		return fmt.Errorf("conflicting flags: choose only one of -n, -h, -M, -V, -g, -R, --duration-sort, --date-sort")
+	}
+	if o.Repeated && o.UniqueOnly {
+		return fmt.Errorf("conflicting flags: --repeated and --unique-only")
+	}
+	if o.Top < 0 || o.Bottom < 0 {
+		return fmt.Errorf("invalid number of lines for --top or --bottom: must not be negative")
+	}
+	if o.Top > 0 && o.Bottom > 0 {
+		return fmt.Errorf("conflicting flags: --top and --bottom")
+	}
+	if (o.Top > 0 || o.Bottom > 0) && (o.Count || o.Repeated || o.UniqueOnly) {
+		return fmt.Errorf("conflicting flags: --top, --bottom and --count, --repeated, --unique-only")
+	}
+	switch o.Format {
+	case "", FormatText, FormatCSV, FormatJSONL:
+	default:
+		return fmt.Errorf("invalid argument %q for --format: choose text, csv or jsonl", o.Format)
+	}
+	switch o.HumanBase {
+	case "", HumanIEC, HumanSI, HumanAuto:
+	default:
+		return fmt.Errorf("invalid argument %q for --human-base: choose si, iec or auto", o.HumanBase)
+	}
+	for _, c := range []struct{ flag, codec string }{
+		{"--compress-output", o.CompressOutput},
+		{"--compress-temp", o.CompressTemp},
+	} {
+		switch c.codec {
+		case "", "gzip", "zstd":
+		default:
+			return fmt.Errorf("invalid argument %q for %s: choose gzip or zstd", c.codec, c.flag)
+		}
+	}
+	for _, k := range o.Keys {
+		if err := k.Validate(); err != nil {
+			return fmt.Errorf("invalid key: %w", err)
+		}
+	}
+	return nil
 }
+
+// CheckMode selects how -c reports disorder.
+type CheckMode string
+
+const (
+	// CheckNone means "sort, do not check"
+	CheckNone CheckMode = ""
+	// CheckDiagnoseFirst means "report the first disorder on stderr" (-c)
+	CheckDiagnoseFirst CheckMode = "diagnose-first"
+	// CheckQuiet means "report nothing, only set the exit status" (-C)
+	CheckQuiet CheckMode = "quiet"
+	// CheckAll means "report every disorder"
+	CheckAll CheckMode = "all"
+)
+
+// Format is the structure of input records.
+type Format string
+
+const (
+	// FormatText means "lines of fields split by -t or blanks"
+	FormatText Format = "text"
+	// FormatCSV means "RFC 4180 records with a header row"
+	FormatCSV Format = "csv"
+	// FormatJSONL means "one JSON value per line, keys are JSON paths"
+	FormatJSONL Format = "jsonl"
+)
+
+// HumanBase selects what -h suffixes multiply by.
+type HumanBase string
+
+const (
+	// HumanIEC means "K, M, G are powers of 1024", like GNU sort -h
+	HumanIEC HumanBase = "iec"
+	// HumanSI means "k, M, G are powers of 1000; KiB, MiB are still 1024"
+	HumanSI HumanBase = "si"
+	// HumanAuto means "kB, MB and k are 1000, while K, M and KiB are 1024"
+	HumanAuto HumanBase = "auto"
+)
@@ -0,0 +1,98 @@
+package parse
+
+import (
+	"strings"
+	"time"
+)
+
+// syslogLayout is time.Stamp with optional fractional seconds.
+const syslogLayout = "Jan _2 15:04:05.999999999"
+
+// namedLayouts are the short names accepted by --date-sort=NAME.
+var namedLayouts = map[string]string{
+	"rfc3339": time.RFC3339Nano,
+	"syslog":  syslogLayout,
+	"iso":     "2006-01-02 15:04:05.999999999",
+	"date":    time.DateOnly,
+}
+
+// autoLayouts are tried in order when no layout is given.
+var autoLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006/01/02 15:04:05.999999999",
+	time.DateOnly,
+	syslogLayout,
+	"02/Jan/2006:15:04:05 -0700", // common log format
+	time.RFC1123Z,
+	time.RFC1123,
+	time.UnixDate,
+	time.ANSIC,
+	time.RFC822Z,
+	time.RFC822,
+}
+
+// maxDateWords limits how many blank-separated words of a key may form a date.
+const maxDateWords = 6
+
+// DateLayout resolves a --date-sort argument: "" and "auto" mean auto-detection,
+// rfc3339, syslog, iso and date are shortcuts, anything else is a Go layout.
+// Returns ("", false) for a layout without any date or time element.
+func DateLayout(name string) (string, bool) {
+	switch name {
+	case "", "auto":
+		return "", true
+	}
+	if layout, ok := namedLayouts[strings.ToLower(name)]; ok {
+		return layout, true
+	}
+	ref := time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC)
+	if ref.Format(name) == name {
+		return "", false
+	}
+	return name, true
+}
+
+// Date parses a timestamp at the start of s with layout, or with common layouts
+// (RFC 3339, syslog, common log format...) when layout is empty.
+// Text after the timestamp is ignored; the longest matching prefix wins.
+// Times without a zone are taken as UTC.
+// Returns (time, true) if parsed, else (zero time, false).
+func Date(s, layout string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, false
+	}
+	layouts := autoLayouts
+	if layout != "" {
+		layouts = []string{layout}
+	}
+
+	ends := wordEnds(s)
+	for i := len(ends) - 1; i >= 0; i-- {
+		prefix := s[:ends[i]]
+		for _, l := range layouts {
+			if t, err := time.Parse(l, prefix); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// wordEnds returns the end offsets of the first blank-separated words of s.
+// Runs of blanks count as one, so "Oct  8" is a two-word prefix.
+func wordEnds(s string) []int {
+	var ends []int
+	for i := 0; i < len(s) && len(ends) < maxDateWords; i++ {
+		if (s[i] == ' ' || s[i] == '\t') && s[i-1] != ' ' && s[i-1] != '\t' {
+			ends = append(ends, i)
+		}
+	}
+	if len(ends) < maxDateWords {
+		ends = append(ends, len(s))
+	}
+	return ends
+}
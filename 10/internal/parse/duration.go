@@ -0,0 +1,20 @@
+package parse
+
+import (
+	"strings"
+	"time"
+)
+
+// Duration parses Go duration strings such as "1h30m", "250ms" or "1.5µs".
+// Returns (seconds, true) if parsed, else (0, false).
+func Duration(s string) (float64, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, false
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, false
+	}
+	return d.Seconds(), true
+}
@@ -6,20 +6,31 @@ import (
 	"unicode"
 )
 
-var pow1024 = []float64{
-	1,
-	1024,
-	math.Pow(1024, 2),
-	math.Pow(1024, 3),
-	math.Pow(1024, 4),
-	math.Pow(1024, 5),
-	math.Pow(1024, 6),
-}
+// HumanBase selects what human-readable prefixes multiply by.
+type HumanBase int
+
+const (
+	// BaseIEC means powers of 1024 for every prefix, like GNU sort -h
+	BaseIEC HumanBase = iota
+	// BaseSI means powers of 1000; explicit binary prefixes (KiB) stay 1024
+	BaseSI
+	// BaseAuto means 1000 for "k" and for "kB", "MB"... and 1024 for "K", "M", "KiB"...
+	BaseAuto
+)
+
+// prefixes are the multiple prefixes in order of their power.
+const prefixes = "KMGTPEZY"
 
 // HumanNumber parses "1K", "1.5M", "2G", "10T", optionally with trailing "B" or "iB".
 // Uses powers of 1024 like GNU sort -h.
 // Returns (value, true) if parsed, else (0, false).
 func HumanNumber(s string) (float64, bool) {
+	return HumanNumberBase(s, BaseIEC)
+}
+
+// HumanNumberBase is HumanNumber with a choice of base. Outside BaseIEC the
+// SI submultiples "m" (milli), "u"/"µ" (micro) and "n" (nano) are recognized too.
+func HumanNumberBase(s string, base HumanBase) (float64, bool) {
 	s = strings.TrimSpace(s)
 	if s == "" {
 		return 0, false
@@ -27,7 +38,7 @@ func HumanNumber(s string) (float64, bool) {
 
 	// separate numeric prefix and suffix
 	i := 0
-	for i < len(s) && (unicode.IsDigit(rune(s[i])) || s[i] == '.' || s[i] == '+' || s[i] == '-') {
+	for i < len(s) && (unicode.IsDigit(rune(s[i])) || s[i] == '.' || s[i] == ',' || s[i] == '+' || s[i] == '-') {
 		i++
 	}
 	numStr := s[:i]
@@ -40,32 +51,49 @@ func HumanNumber(s string) (float64, bool) {
 	if suf == "" {
 		return num, true
 	}
-	// Normalize suffix
-	suf = strings.ToUpper(suf)
+
 	// allow trailing B or iB
-	suf = strings.TrimSuffix(suf, "B")
-	suf = strings.TrimSuffix(suf, "IB")
+	hasB := strings.HasSuffix(suf, "B") || strings.HasSuffix(suf, "b")
+	if hasB {
+		suf = suf[:len(suf)-1]
+	}
+	binary := strings.HasSuffix(suf, "i") || strings.HasSuffix(suf, "I")
+	if binary {
+		suf = suf[:len(suf)-1]
+	}
+	if suf == "" {
+		if binary {
+			// "1iB" is not a size
+			return 0, false
+		}
+		return num, true
+	}
 
-	mul := 1.0
-	switch suf {
-	case "":
-		mul = 1
-	case "K":
-		mul = pow1024[1]
-	case "M":
-		mul = pow1024[2]
-	case "G":
-		mul = pow1024[3]
-	case "T":
-		mul = pow1024[4]
-	case "P":
-		mul = pow1024[5]
-	case "E":
-		mul = pow1024[6]
-	default:
+	if base != BaseIEC && !binary {
+		switch suf {
+		case "m":
+			return num * 1e-3, true
+		case "u", "µ", "μ":
+			return num * 1e-6, true
+		case "n":
+			return num * 1e-9, true
+		}
+	}
+
+	power := strings.Index(prefixes, strings.ToUpper(suf))
+	if len(suf) != 1 || power < 0 {
 		// Unknown suffix -> not human numeric
 		return 0, false
 	}
+	power++
 
-	return num * mul, true
+	unit := 1024.0
+	switch {
+	case binary || base == BaseIEC:
+	case base == BaseSI:
+		unit = 1000
+	case base == BaseAuto && (hasB || suf == "k"):
+		unit = 1000
+	}
+	return num * math.Pow(unit, float64(power)), true
 }
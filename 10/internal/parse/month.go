@@ -2,20 +2,21 @@ package parse
 
 import "strings"
 
-// Map of month abbreviations and full names (case-insensitive) to 1..12
+// Map of month abbreviations and full names (case-insensitive) to 1..12,
+// in English and in Russian (nominative and genitive: "январь", "января")
 var months = map[string]int{
-	"JAN": 1,
-	"FEB": 2,
-	"MAR": 3,
-	"APR": 4,
-	"MAY": 5,
-	"JUN": 6,
-	"JUL": 7,
-	"AUG": 8,
-	"SEP": 9,
-	"OCT": 10,
-	"NOV": 11,
-	"DEC": 12,
+	"JAN": 1, "JANUARY": 1, "ЯНВ": 1, "ЯНВАРЬ": 1, "ЯНВАРЯ": 1,
+	"FEB": 2, "FEBRUARY": 2, "ФЕВ": 2, "ФЕВРАЛЬ": 2, "ФЕВРАЛЯ": 2,
+	"MAR": 3, "MARCH": 3, "МАР": 3, "МАРТ": 3, "МАРТА": 3,
+	"APR": 4, "APRIL": 4, "АПР": 4, "АПРЕЛЬ": 4, "АПРЕЛЯ": 4,
+	"MAY": 5, "МАЙ": 5, "МАЯ": 5,
+	"JUN": 6, "JUNE": 6, "ИЮН": 6, "ИЮНЬ": 6, "ИЮНЯ": 6,
+	"JUL": 7, "JULY": 7, "ИЮЛ": 7, "ИЮЛЬ": 7, "ИЮЛЯ": 7,
+	"AUG": 8, "AUGUST": 8, "АВГ": 8, "АВГУСТ": 8, "АВГУСТА": 8,
+	"SEP": 9, "SEPT": 9, "SEPTEMBER": 9, "СЕН": 9, "СЕНТ": 9, "СЕНТЯБРЬ": 9, "СЕНТЯБРЯ": 9,
+	"OCT": 10, "OCTOBER": 10, "ОКТ": 10, "ОКТЯБРЬ": 10, "ОКТЯБРЯ": 10,
+	"NOV": 11, "NOVEMBER": 11, "НОЯ": 11, "НОЯБ": 11, "НОЯБРЬ": 11, "НОЯБРЯ": 11,
+	"DEC": 12, "DECEMBER": 12, "ДЕК": 12, "ДЕКАБРЬ": 12, "ДЕКАБРЯ": 12,
 }
 
 // Month tries to extract a month number from s.
@@ -1,21 +1,127 @@
 package parse
 
 import (
+	"errors"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
 // FloatLoose tries to parse a float from the string, trimming spaces.
+// Thousands separators are accepted between groups of three digits:
+// "1,234,567.8", "1_000", "1'000" or with no-break spaces.
 // Returns (value, true) if parsed, otherwise (0, false).
 func FloatLoose(s string) (float64, bool) {
+	return FloatLocale(s, '.')
+}
+
+// FloatLocale is FloatLoose for locales with another decimal separator:
+// with ',' it parses "1.234.567,8" and "1 234 567,8" (no-break spaces).
+func FloatLocale(s string, decimal rune) (float64, bool) {
 	s = strings.TrimSpace(s)
 	if s == "" {
 		return 0, false
 	}
-	// Allow underscores like 1_000? Keep it strict.
-	v, err := strconv.ParseFloat(s, 64)
+
+	// rewrite into the form strconv expects: no groups, '.' as decimal point
+	var b strings.Builder
+	i := 0
+	if s[0] == '+' || s[0] == '-' {
+		b.WriteByte(s[0])
+		i++
+	}
+	lead, group := 0, -1 // digits before the first separator, digits in the current group
+	for i < len(s) {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+			if group >= 0 {
+				group++
+			} else {
+				lead++
+			}
+			i += size
+			continue
+		}
+		if isGroupSeparator(r, decimal) {
+			if (group < 0 && (lead == 0 || lead > 3)) || (group >= 0 && group != 3) {
+				return 0, false
+			}
+			group = 0
+			i += size
+			continue
+		}
+		break
+	}
+	if group >= 0 && group != 3 {
+		return 0, false
+	}
+	if i < len(s) {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == decimal {
+			b.WriteByte('.')
+			i += size
+		}
+		b.WriteString(s[i:])
+	}
+
+	v, err := strconv.ParseFloat(b.String(), 64)
 	if err != nil {
 		return 0, false
 	}
 	return v, true
 }
+
+func isGroupSeparator(r, decimal rune) bool {
+	if r == decimal {
+		return false
+	}
+	switch r {
+	case ',', '.', '\'', '_', '\u00a0', '\u202f', '\u2009':
+		return true
+	default:
+		return false
+	}
+}
+
+// decimalComma lists languages that write "1,5" for one and a half.
+var decimalComma = map[string]bool{
+	"az": true, "be": true, "bg": true, "cs": true, "da": true, "de": true, "el": true,
+	"es": true, "et": true, "fi": true, "fr": true, "hr": true, "hu": true, "hy": true,
+	"id": true, "it": true, "ka": true, "kk": true, "lt": true, "lv": true, "nb": true,
+	"nl": true, "nn": true, "no": true, "pl": true, "pt": true, "ro": true, "ru": true,
+	"sk": true, "sl": true, "sr": true, "sv": true, "tr": true, "uk": true, "vi": true,
+}
+
+// DecimalSeparator returns the decimal separator of a locale such as "ru_RU.UTF-8".
+func DecimalSeparator(locale string) rune {
+	lang := locale
+	if i := strings.IndexAny(lang, "_-.@"); i >= 0 {
+		lang = lang[:i]
+	}
+	if decimalComma[strings.ToLower(lang)] {
+		return ','
+	}
+	return '.'
+}
+
+// General parses a float like GNU sort -g: exponents ("1e-3"), hex floats,
+// infinities ("inf", "-Infinity") and NaN are accepted. Out-of-range values
+// are clamped to ±Inf or 0 rather than rejected.
+// Returns (value, true) if parsed, otherwise (0, false).
+func General(s string) (float64, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, false
+	}
+	unsigned := strings.TrimLeft(s, "+-")
+	if (strings.HasPrefix(unsigned, "0x") || strings.HasPrefix(unsigned, "0X")) && !strings.ContainsAny(unsigned, "pP") {
+		// strconv wants a binary exponent in hex floats, strtod does not
+		s += "p0"
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil && !errors.Is(err, strconv.ErrRange) {
+		return 0, false
+	}
+	return v, true
+}
@@ -0,0 +1,22 @@
+package parse
+
+import "strings"
+
+// ByteSize parses a memory size such as "512K", "64M" or "1G".
+// Like GNU sort -S, a bare number means KiB and a "b" suffix means bytes.
+// Returns (bytes, true) if parsed, else (0, false).
+func ByteSize(s string) (int64, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, false
+	}
+	last := s[len(s)-1]
+	if last >= '0' && last <= '9' {
+		s += "K"
+	}
+	v, ok := HumanNumber(s)
+	if !ok || v < 0 {
+		return 0, false
+	}
+	return int64(v), true
+}
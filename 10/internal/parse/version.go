@@ -0,0 +1,130 @@
+package parse
+
+// CompareVersions compares strings containing version numbers like GNU sort -V
+// (gnulib filevercmp): "v1.9.2" < "v1.10.0", "1.0~rc1" < "1.0", and file
+// suffixes such as ".tar.gz" only matter when the rest is equal.
+// Returns -1, 0 or 1.
+func CompareVersions(a, b string) int {
+	if a == b {
+		return 0
+	}
+	// empty strings first
+	if a == "" {
+		return -1
+	}
+	if b == "" {
+		return 1
+	}
+	// "." first, then "..", then other names with a leading dot, then the rest
+	if a[0] == '.' || b[0] == '.' {
+		if a[0] != '.' {
+			return 1
+		}
+		if b[0] != '.' {
+			return -1
+		}
+		for _, special := range []string{".", ".."} {
+			if a == special {
+				return -1
+			}
+			if b == special {
+				return 1
+			}
+		}
+	}
+
+	ap, bp := versionPrefixLen(a), versionPrefixLen(b)
+	result := verrevcmp(a[:ap], b[:bp])
+	if result != 0 || (ap == len(a) && bp == len(b)) {
+		return sign(result)
+	}
+	return sign(verrevcmp(a, b))
+}
+
+// versionPrefixLen returns the length of s without its file suffix,
+// which matches (\.[A-Za-z~][A-Za-z0-9~]*)*$.
+func versionPrefixLen(s string) int {
+	prefix := 0
+	for i := 0; i < len(s); {
+		i++
+		prefix = i
+		for i+1 < len(s) && s[i] == '.' && (isAlpha(s[i+1]) || s[i+1] == '~') {
+			for i += 2; i < len(s) && (isAlpha(s[i]) || isDigit(s[i]) || s[i] == '~'); i++ {
+			}
+		}
+	}
+	return prefix
+}
+
+// verrevcmp compares alternating non-digit and digit runs, as Debian versions do.
+func verrevcmp(a, b string) int {
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		for (i < len(a) && !isDigit(a[i])) || (j < len(b) && !isDigit(b[j])) {
+			ac, bc := versionOrder(a, i), versionOrder(b, j)
+			if ac != bc {
+				return ac - bc
+			}
+			i++
+			j++
+		}
+		for i < len(a) && a[i] == '0' {
+			i++
+		}
+		for j < len(b) && b[j] == '0' {
+			j++
+		}
+		firstDiff := 0
+		for i < len(a) && j < len(b) && isDigit(a[i]) && isDigit(b[j]) {
+			if firstDiff == 0 {
+				firstDiff = int(a[i]) - int(b[j])
+			}
+			i++
+			j++
+		}
+		if i < len(a) && isDigit(a[i]) {
+			return 1
+		}
+		if j < len(b) && isDigit(b[j]) {
+			return -1
+		}
+		if firstDiff != 0 {
+			return firstDiff
+		}
+	}
+	return 0
+}
+
+// versionOrder ranks a character: '~' sorts before the end of the string,
+// which sorts before digits, letters and then everything else.
+func versionOrder(s string, i int) int {
+	if i >= len(s) {
+		return -1
+	}
+	c := s[i]
+	switch {
+	case isDigit(c):
+		return 0
+	case isAlpha(c):
+		return int(c)
+	case c == '~':
+		return -2
+	default:
+		return int(c) + 256
+	}
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+func isAlpha(c byte) bool { return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') }
+
+func sign(v int) int {
+	switch {
+	case v < 0:
+		return -1
+	case v > 0:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -5,51 +5,142 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strings"
+
+	"gosort/internal/codec"
 )
 
-// OpenInput returns an opened file
-func OpenInput(args []string) (io.ReadCloser, error) {
+// OpenInputs returns opened files; no names or "-" mean stdin.
+// gzip, bzip2 and zstd inputs are recognized by their magic bytes and decompressed.
+func OpenInputs(args []string) ([]io.ReadCloser, error) {
 	if len(args) == 0 {
-		// os.Stdin is already a ReadCloser
-		return os.Stdin, nil
+		args = []string{"-"}
+	}
+	files := make([]io.ReadCloser, 0, len(args))
+	for _, name := range args {
+		var f *os.File
+		if name == "-" {
+			f = os.Stdin
+		} else {
+			var err error
+			if f, err = os.Open(name); err != nil {
+				CloseAll(files)
+				return nil, fmt.Errorf("open file: %w", err)
+			}
+		}
+		in, err := decompress(f)
+		if err != nil {
+			_ = in.Close()
+			CloseAll(files)
+			return nil, fmt.Errorf("open file %s: %w", name, err)
+		}
+		files = append(files, in)
 	}
-	f, err := os.Open(args[0])
+	return files, nil
+}
+
+// CloseAll closes every file except stdin.
+func CloseAll(files []io.ReadCloser) {
+	for _, f := range files {
+		_ = f.Close()
+	}
+}
+
+// input is a possibly compressed file; closing it closes the decoder
+// and the file, unless the file is stdin.
+type input struct {
+	io.ReadCloser
+	f *os.File
+}
+
+func decompress(f *os.File) (*input, error) {
+	in := &input{f: f}
+	rc, err := codec.NewReader(f)
 	if err != nil {
-		return nil, fmt.Errorf("open file: %w", err)
+		return in, err
+	}
+	in.ReadCloser = rc
+	return in, nil
+}
+
+func (in *input) Close() error {
+	if in.ReadCloser != nil {
+		_ = in.ReadCloser.Close()
+	}
+	if in.f == os.Stdin {
+		return nil
 	}
-	return f, nil
+	return in.f.Close()
 }
 
-// ReadAllLines reads all lines from r without imposing Scanner's token limit.
-func ReadAllLines(r io.Reader) ([]string, error) {
-	rd := bufio.NewReaderSize(r, 64*1024)
-	var lines []string
-	for {
-		line, err := rd.ReadString('\n')
+// LineReader reads lines one by one without imposing Scanner's token limit.
+type LineReader struct {
+	rd    *bufio.Reader
+	delim byte
+}
+
+// NewLineReader creates a LineReader over r.
+// Lines end with delim: '\n' (with an optional '\r' before it) or '\x00' for -z.
+func NewLineReader(delim byte, r io.Reader) *LineReader {
+	return &LineReader{
+		rd:    bufio.NewReaderSize(r, 64*1024),
+		delim: delim,
+	}
+}
+
+// Next returns the next line without its terminator.
+// Returns io.EOF when there are no more lines.
+func (lr *LineReader) Next() (string, error) {
+	line, err := lr.rd.ReadString(lr.delim)
+	if err == io.EOF {
+		if len(line) == 0 {
+			return "", io.EOF
+		}
+		// last line without newline
+	} else if err != nil {
+		return "", err
+	}
+	// normalize: strip the trailing delimiter and an optional '\r' before '\n'
+	if n := len(line); n > 0 {
+		if line[n-1] == lr.delim {
+			line = line[:n-1]
+			n--
+		}
+		if lr.delim == '\n' && n > 0 && line[n-1] == '\r' {
+			line = line[:n-1]
+		}
+	}
+	return line, nil
+}
+
+// CSVReader joins physical lines into CSV records, so that quoted fields
+// may span several lines.
+type CSVReader struct {
+	lr *LineReader
+}
+
+// NewCSVReader creates a CSVReader over lr.
+func NewCSVReader(lr *LineReader) *CSVReader {
+	return &CSVReader{lr: lr}
+}
+
+// Next returns the next record, with inner line breaks kept as '\n'.
+// Returns io.EOF when there are no more records.
+func (r *CSVReader) Next() (string, error) {
+	record, err := r.lr.Next()
+	if err != nil {
+		return "", err
+	}
+	// an odd number of quotes means a quoted field is still open
+	for strings.Count(record, `"`)%2 == 1 {
+		line, err := r.lr.Next()
 		if err == io.EOF {
-			if len(line) > 0 {
-				// last line without newline
-				if line[len(line)-1] == '\r' {
-					line = line[:len(line)-1]
-				}
-				lines = append(lines, line)
-			}
 			break
 		}
 		if err != nil {
-			return nil, err
-		}
-		// normalize: strip trailing '\n' and optional '\r'
-		if n := len(line); n > 0 {
-			if line[n-1] == '\n' {
-				line = line[:n-1]
-				n--
-			}
-			if n > 0 && line[n-1] == '\r' {
-				line = line[:n-1]
-			}
+			return "", err
 		}
-		lines = append(lines, line)
+		record += "\n" + line
 	}
-	return lines, nil
+	return record, nil
 }
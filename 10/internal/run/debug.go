@@ -0,0 +1,85 @@
+package run
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"gosort/internal/options"
+	isort "gosort/internal/sort"
+)
+
+// debugger annotates output lines for --debug, like GNU sort: every key is
+// underlined, then its parsed value and the branch of Compare that ordered
+// the line after the previous one are printed.
+type debugger struct {
+	comparator isort.Comparator
+	indent     int // width of the --count prefix
+	prev       isort.Record
+	hasPrev    bool
+}
+
+func newDebugger(opt options.Options, comparator isort.Comparator) *debugger {
+	locale := "simple byte comparison"
+	if name, _, _ := strings.Cut(opt.Locale, "."); name != "" && name != "C" && name != "POSIX" {
+		locale = "collation rules of " + opt.Locale
+	}
+	log := opt.DebugLog
+	if log == nil {
+		log = io.Discard
+	}
+	_, _ = fmt.Fprintf(log, "gosort: text ordering performed using %s\n", locale)
+	if opt.Format != options.FormatText && opt.Format != "" {
+		_, _ = fmt.Fprintf(log, "gosort: keys of --format=%s are not underlined\n", opt.Format)
+	}
+	d := &debugger{comparator: comparator}
+	if opt.Count {
+		d.indent = 8
+	}
+	return d
+}
+
+// annotate writes the notes for rec, which has just been written.
+func (d *debugger) annotate(w io.Writer, rec isort.Record) error {
+	var b strings.Builder
+	pad := strings.Repeat(" ", d.indent)
+	for i, def := range d.comparator.Keys() {
+		if start, end, ok := isort.KeySpan(def.Extractor, rec.Line); ok {
+			b.WriteString(pad)
+			b.WriteString(underline(rec.Line, start, end))
+			b.WriteByte('\n')
+		}
+		_, _ = fmt.Fprintf(&b, "%skey %d: %s\n", pad, i+1, isort.Describe(rec.Keys[i], def))
+	}
+	if d.hasPrev {
+		_, _ = fmt.Fprintf(&b, "%svs previous: %s\n", pad, d.comparator.Explain(d.prev, rec))
+	}
+	d.prev, d.hasPrev = rec, true
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// underline marks line[start:end] with underscores, keeping tabs so that
+// the marks line up with the text above them.
+func underline(line string, start, end int) string {
+	var b strings.Builder
+	for _, r := range line[:start] {
+		if r == '\t' {
+			b.WriteByte('\t')
+		} else {
+			b.WriteByte(' ')
+		}
+	}
+	if start == end {
+		b.WriteString("^ no match for key")
+		return b.String()
+	}
+	for _, r := range line[start:end] {
+		if r == '\t' {
+			b.WriteByte('\t')
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
@@ -2,115 +2,240 @@ package run
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
-	"gosort/internal/util"
 	"io"
-	"os"
-	"sort"
 
+	"gosort/internal/codec"
+	"gosort/internal/extsort"
 	"gosort/internal/options"
 	"gosort/internal/reader"
 	isort "gosort/internal/sort"
 )
 
-// Run runs the program
-func Run(opt options.Options, args []string) error {
-	in, err := reader.OpenInput(args)
+// ErrDisorder is returned by Check when the input is not sorted.
+var ErrDisorder = errors.New("input is not sorted")
+
+// Sort sorts the lines of inputs, read one after another, into w.
+// With opt.Merge the inputs must be sorted already and are merged without loading them.
+func Sort(opt options.Options, inputs []io.Reader, w io.Writer) error {
+	comparator, err := isort.NewComparator(opt)
 	if err != nil {
 		return err
 	}
-	defer func() {
-		if in != os.Stdin {
-			_ = in.Close()
-		}
-	}()
-
-	extractor := isort.NewExtractor(opt)
-	comparator := isort.NewComparator(opt)
+	makeRecord := func(line string) isort.Record {
+		return isort.MakeRecord(line, comparator)
+	}
 
-	if opt.Check {
-		return checkSorted(in, extractor, comparator, opt)
+	delim := delimiter(opt)
+	sources, header, err := openSources(opt, inputs)
+	if err != nil {
+		return err
 	}
 
-	lines, err := reader.ReadAllLines(in)
+	zw, err := codec.NewWriter(w, codec.Codec(opt.CompressOutput))
 	if err != nil {
 		return err
 	}
+	bw := bufio.NewWriterSize(zw, 64*1024)
+	// the header of the first input stays on top, the others are dropped
+	if len(header) > 0 {
+		if _, err := bw.WriteString(header[0]); err != nil {
+			return err
+		}
+		if err := bw.WriteByte(delim); err != nil {
+			return err
+		}
+	}
+	var debug *debugger
+	if opt.Debug {
+		debug = newDebugger(opt, comparator)
+	}
+	write := func(rec isort.Record, count int) error {
+		if opt.Count {
+			if _, err := fmt.Fprintf(bw, "%7d ", count); err != nil {
+				return err
+			}
+		}
+		if _, err := bw.WriteString(rec.Line); err != nil {
+			return err
+		}
+		if err := bw.WriteByte(delim); err != nil {
+			return err
+		}
+		if debug != nil {
+			return debug.annotate(bw, rec)
+		}
+		return nil
+	}
+	var emit func(isort.Record) error
+	var g *grouper
+	if opt.Unique || opt.Count || opt.Repeated || opt.UniqueOnly {
+		g = &grouper{comparator: comparator, keep: keepGroup(opt), write: write}
+		emit = g.add
+	} else {
+		emit = func(rec isort.Record) error { return write(rec, 1) }
+	}
 
-	if opt.IgnoreTrailingBlanks {
-		for i := range lines {
-			lines[i] = util.TrimRightSpace(lines[i])
+	if opt.Top > 0 || opt.Bottom > 0 {
+		// a bounded heap instead of a full sort; the inputs need not be sorted
+		var same func(a, b isort.Record) bool
+		if opt.Unique {
+			same = func(a, b isort.Record) bool { return isort.SameKey(a, b, comparator) }
+		}
+		err = extsort.Top(extsort.Concat(sources...), extsort.TopConfig{
+			N:          max(opt.Top, opt.Bottom),
+			Bottom:     opt.Bottom > 0,
+			Same:       same,
+			Compare:    comparator.Compare,
+			MakeRecord: makeRecord,
+		}, emit)
+	} else if opt.Merge {
+		// inputs are already sorted: stream a k-way merge without loading them
+		records := make([]extsort.Source, len(sources))
+		for i, src := range sources {
+			records[i] = extsort.Records(src, makeRecord)
+		}
+		err = extsort.Merge(records, comparator.Compare, emit)
+	} else {
+		sorter := extsort.New(extsort.Config{
+			BufferSize: opt.BufferSize,
+			TempDir:    opt.TempDir,
+			Parallel:   opt.Parallel,
+			Compress:   codec.Codec(opt.CompressTemp),
+			Compare:    comparator.Compare,
+			MakeRecord: makeRecord,
+		})
+		err = sorter.Sort(extsort.Concat(sources...), emit)
+	}
+	if err != nil {
+		return err
+	}
+	if g != nil {
+		if err := g.flush(); err != nil {
+			return err
 		}
 	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// Check reads r and calls report with the 1-based number and the text of every
+// line that sorts before the previous one; report returns false to stop early.
+// Returns ErrDisorder if any line was out of order.
+func Check(opt options.Options, r io.Reader, report func(lineNo int, line string) bool) error {
+	comparator, err := isort.NewComparator(opt)
+	if err != nil {
+		return err
+	}
+	sources, _, err := openSources(opt, []io.Reader{r})
+	if err != nil {
+		return err
+	}
+	return checkSorted(sources[0], comparator, report)
+}
 
-	records := isort.BuildRecords(lines, extractor, comparator)
+func delimiter(opt options.Options) byte {
+	if opt.ZeroTerminated {
+		return 0
+	}
+	return '\n'
+}
 
-	sort.Slice(records, func(i, j int) bool {
-		cmp := comparator.Compare(records[i], records[j])
-		if opt.Reverse {
-			return cmp > 0
+// openSources makes one source per input; CSV inputs yield whole records,
+// and their headers are consumed and returned.
+func openSources(opt options.Options, inputs []io.Reader) ([]extsort.LineSource, []string, error) {
+	sources := make([]extsort.LineSource, len(inputs))
+	for i, in := range inputs {
+		lr := reader.NewLineReader(delimiter(opt), in)
+		if opt.Format == options.FormatCSV {
+			sources[i] = reader.NewCSVReader(lr)
+		} else {
+			sources[i] = lr
 		}
-		return cmp < 0
-	})
+	}
+	if opt.Format != options.FormatCSV {
+		return sources, nil, nil
+	}
+	header, err := readHeaders(sources)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sources, header, nil
+}
+
+// grouper collapses runs of records with equal keys (see isort.SameKey)
+// into their first line, for -u, --count, --repeated and --unique-only.
+type grouper struct {
+	comparator isort.Comparator
+	keep       func(count int) bool
+	write      func(rec isort.Record, count int) error
+
+	first isort.Record
+	count int
+}
 
-	if opt.Unique {
-		records = isort.Unique(records, comparator)
+// keepGroup decides which groups are printed, by the number of their lines.
+func keepGroup(opt options.Options) func(count int) bool {
+	switch {
+	case opt.Repeated:
+		return func(count int) bool { return count > 1 }
+	case opt.UniqueOnly:
+		return func(count int) bool { return count == 1 }
+	default:
+		return func(int) bool { return true }
 	}
+}
 
-	// Write to stdout
-	w := bufio.NewWriterSize(os.Stdout, 64*1024)
-	for _, rec := range records {
-		_, _ = w.WriteString(rec.Line)
-		_, _ = w.WriteString("\n")
+func (g *grouper) add(rec isort.Record) error {
+	if g.count > 0 && isort.SameKey(g.first, rec, g.comparator) {
+		g.count++
+		return nil
+	}
+	if err := g.flush(); err != nil {
+		return err
 	}
-	_ = w.Flush()
+	g.first, g.count = rec, 1
 	return nil
 }
 
-func checkSorted(in io.Reader, extractor isort.Extractor, comparator isort.Comparator, opt options.Options) error {
-	rd := bufio.NewReaderSize(in, 64*1024)
+// flush writes the current group, if any and if it is kept.
+func (g *grouper) flush() error {
+	count := g.count
+	g.count = 0
+	if count == 0 || !g.keep(count) {
+		return nil
+	}
+	return g.write(g.first, count)
+}
 
-	readLine := func() (string, error) {
-		line, err := rd.ReadString('\n')
+// readHeaders consumes the first record of every source.
+func readHeaders(sources []extsort.LineSource) ([]string, error) {
+	var headers []string
+	for _, src := range sources {
+		line, err := src.Next()
 		if err == io.EOF {
-			if len(line) == 0 {
-				return "", io.EOF
-			}
-			// strip eol
-			if n := len(line); n > 0 {
-				if line[n-1] == '\n' {
-					line = line[:n-1]
-					n--
-				}
-				if n > 0 && line[n-1] == '\r' {
-					line = line[:n-1]
-				}
-			}
-			return line, nil
+			continue
 		}
 		if err != nil {
-			return "", err
-		}
-		// strip eol
-		if n := len(line); n > 0 {
-			if line[n-1] == '\n' {
-				line = line[:n-1]
-				n--
-			}
-			if n > 0 && line[n-1] == '\r' {
-				line = line[:n-1]
-			}
+			return nil, err
 		}
-		return line, nil
+		headers = append(headers, line)
 	}
+	return headers, nil
+}
 
+func checkSorted(lr extsort.LineSource, comparator isort.Comparator, report func(lineNo int, line string) bool) error {
 	var (
 		prevLine string
 		lineNo   = 0
+		sorted   = true
 	)
 
 	// read first line
-	first, err := readLine()
+	first, err := lr.Next()
 	if err == io.EOF {
 		// empty input is sorted
 		return nil
@@ -120,10 +245,10 @@ func checkSorted(in io.Reader, extractor isort.Extractor, comparator isort.Compa
 	}
 	lineNo = 1
 	prevLine = first
-	prevRec := isort.MakeRecord(prevLine, extractor, comparator)
+	prevRec := isort.MakeRecord(prevLine, comparator)
 
 	for {
-		curLine, err := readLine()
+		curLine, err := lr.Next()
 		if err == io.EOF {
 			break
 		}
@@ -131,19 +256,19 @@ func checkSorted(in io.Reader, extractor isort.Extractor, comparator isort.Compa
 			return err
 		}
 		lineNo++
-		curRec := isort.MakeRecord(curLine, extractor, comparator)
-		cmp := comparator.Compare(prevRec, curRec)
-		if opt.Reverse {
-			cmp = -cmp
-		}
-		if cmp > 0 {
+		curRec := isort.MakeRecord(curLine, comparator)
+		if comparator.Compare(prevRec, curRec) > 0 {
 			// disorder found at current line
-			_, _ = fmt.Fprintf(os.Stdout, "Data is not sorted at line %d\n", lineNo)
-			return fmt.Errorf("not sorted at line %d", lineNo)
+			sorted = false
+			if !report(lineNo, curLine) {
+				return ErrDisorder
+			}
 		}
 		prevRec = curRec
 	}
 
-	// sorted
+	if !sorted {
+		return ErrDisorder
+	}
 	return nil
 }
@@ -0,0 +1,66 @@
+package sort
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"unicode"
+
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+// Collation computes locale-aware sort keys. It is safe for concurrent use.
+type Collation struct {
+	pool sync.Pool
+}
+
+// NewCollation creates a Collation for a locale such as "ru_RU.UTF-8" or "de".
+// Returns nil for "", "C" and "POSIX", which mean byte order.
+func NewCollation(locale string) (*Collation, error) {
+	name, _, _ := strings.Cut(locale, ".")
+	if name == "" || name == "C" || name == "POSIX" {
+		return nil, nil
+	}
+	tag, err := language.Parse(strings.ReplaceAll(name, "_", "-"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid locale %q: %w", locale, err)
+	}
+	c := &Collation{}
+	c.pool.New = func() any {
+		// collate.Collator keeps internal buffers, so each goroutine needs its own
+		return collate.New(tag)
+	}
+	return c, nil
+}
+
+// Key returns a sort key that orders byte-wise like s orders under the locale.
+func (c *Collation) Key(s string) string {
+	col := c.pool.Get().(*collate.Collator)
+	var buf collate.Buffer
+	key := string(col.KeyFromString(&buf, s))
+	c.pool.Put(col)
+	return key
+}
+
+// transform returns a function applying -f, -d and -i to string keys,
+// or nil when none of them is set.
+func transform(foldCase, dictionary, ignoreNonPrinting bool) func(string) string {
+	if !foldCase && !dictionary && !ignoreNonPrinting {
+		return nil
+	}
+	return func(s string) string {
+		return strings.Map(func(r rune) rune {
+			if dictionary && !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != ' ' && r != '\t' {
+				return -1
+			}
+			if ignoreNonPrinting && !unicode.IsPrint(r) {
+				return -1
+			}
+			if foldCase {
+				return unicode.ToUpper(r)
+			}
+			return r
+		}, s)
+	}
+}
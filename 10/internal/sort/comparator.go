@@ -1,6 +1,10 @@
 package sort
 
 import (
+	"math"
+	"strings"
+	"unicode"
+
 	"gosort/internal/options"
 	"gosort/internal/parse"
 )
@@ -8,95 +12,224 @@ import (
 // Comparator is an interface that can compare records
 type Comparator interface {
 	Compare(a, b Record) int
-	Mode() Mode
+	Keys() []KeyDef
+	// Explain tells which branch of Compare ordered a and b, for --debug.
+	Explain(a, b Record) string
+}
+
+// KeyDef is a single sort key: where to find it and how to compare it.
+type KeyDef struct {
+	Extractor Extractor
+	Mode      Mode
+	Reverse   bool
+	// Transform rewrites string keys before comparison (-f, -d, -i); may be nil.
+	Transform func(string) string
+	// Collation orders string keys by locale; nil means byte order.
+	Collation *Collation
+	// Random hashes keys for ModeRandom.
+	Random *RandomHash
+	// HumanBase chooses the multiplier of ModeHuman suffixes.
+	HumanBase parse.HumanBase
+	// Decimal is the decimal separator of ModeNumeric keys, '.' or ','.
+	Decimal rune
+	// DateLayout is the Go time layout of ModeDate keys; empty means auto-detect.
+	DateLayout string
 }
 
 type comparator struct {
-	mode      Mode
-	extractor Extractor
+	keys    []KeyDef
+	reverse bool // applies to the last-resort comparison of whole lines
+	stable  bool // disables tie-breaks and the last-resort comparison
 }
 
 // NewComparator creates a comparator
-func NewComparator(opt options.Options) Comparator {
-	var mode Mode
+func NewComparator(opt options.Options) (Comparator, error) {
+	collation, err := NewCollation(opt.Locale)
+	if err != nil {
+		return nil, err
+	}
+	random := NewRandomHash(opt.RandomSeed)
+	decimal := parse.DecimalSeparator(opt.Locale)
+	specs := opt.Keys
+	if len(specs) == 0 {
+		// no -k: the whole line is the only key
+		specs = []options.KeySpec{{}}
+	}
+	keys := make([]KeyDef, 0, len(specs))
+	for _, spec := range specs {
+		spec = spec.Inherit(opt)
+		keys = append(keys, KeyDef{
+			Extractor:  NewExtractor(spec, opt),
+			Mode:       modeOf(spec),
+			Reverse:    spec.Reverse,
+			Transform:  transform(spec.FoldCase, spec.Dictionary, spec.IgnoreNonPrinting),
+			Collation:  collation,
+			Random:     random,
+			HumanBase:  humanBase(opt.HumanBase),
+			Decimal:    decimal,
+			DateLayout: opt.DateLayout,
+		})
+	}
+	return &comparator{
+		keys:    keys,
+		reverse: opt.Reverse,
+		stable:  opt.Stable,
+	}, nil
+}
+
+func modeOf(spec options.KeySpec) Mode {
 	switch {
-	case opt.HumanNumeric:
-		mode = ModeHuman
-	case opt.Numeric:
-		mode = ModeNumeric
-	case opt.Month:
-		mode = ModeMonth
+	case spec.HumanNumeric:
+		return ModeHuman
+	case spec.Numeric:
+		return ModeNumeric
+	case spec.Month:
+		return ModeMonth
+	case spec.Version:
+		return ModeVersion
+	case spec.GeneralNumeric:
+		return ModeGeneral
+	case spec.Random:
+		return ModeRandom
+	case spec.Duration:
+		return ModeDuration
+	case spec.Date:
+		return ModeDate
 	default:
-		mode = ModeString
+		return ModeString
 	}
-	return &comparator{
-		mode: mode,
+}
+
+func humanBase(base options.HumanBase) parse.HumanBase {
+	switch base {
+	case options.HumanSI:
+		return parse.BaseSI
+	case options.HumanAuto:
+		return parse.BaseAuto
+	default:
+		return parse.BaseIEC
 	}
 }
 
-// Mode returns mode.
-func (c *comparator) Mode() Mode { return c.mode }
+// Keys returns key definitions in priority order.
+func (c *comparator) Keys() []KeyDef { return c.keys }
 
 // MakeRecord creates a record and returns it.
-func MakeRecord(line string, extractor Extractor, cmp Comparator) Record {
-	key := extractor.Key(line)
+func MakeRecord(line string, cmp Comparator) Record {
+	defs := cmp.Keys()
 	rec := Record{
-		Line:     line,
-		Key:      key,
-		SortMode: cmp.Mode(),
+		Line: line,
+		Keys: make([]Key, len(defs)),
 	}
-	switch cmp.Mode() {
+	for i, def := range defs {
+		rec.Keys[i] = makeKey(def.Extractor.Key(line), def)
+	}
+	return rec
+}
+
+func makeKey(text string, def KeyDef) Key {
+	key := Key{Text: text}
+	switch def.Mode {
 	case ModeNumeric:
-		if v, ok := parse.FloatLoose(key); ok {
-			rec.Num = v
-			rec.HasNum = true
+		if v, ok := parse.FloatLocale(leadingToken(text), def.Decimal); ok {
+			key.Num = v
+			key.HasNum = true
 		}
 	case ModeHuman:
-		if v, ok := parse.HumanNumber(key); ok {
-			rec.Num = v
-			rec.HasNum = true
+		if v, ok := parse.HumanNumberBase(leadingToken(text), def.HumanBase); ok {
+			key.Num = v
+			key.HasNum = true
+		}
+	case ModeGeneral:
+		if v, ok := parse.General(leadingToken(text)); ok {
+			key.Num = v
+			key.HasNum = true
+		}
+	case ModeDuration:
+		if v, ok := parse.Duration(leadingToken(text)); ok {
+			key.Num = v
+			key.HasNum = true
 		}
+	case ModeDate:
+		if t, ok := parse.Date(text, def.DateLayout); ok {
+			key.Time = t
+			key.HasTime = true
+		}
+	case ModeVersion:
 	case ModeMonth:
-		if m, ok := parse.Month(key); ok {
-			rec.Month = m
-			rec.HasMonth = true
+		if m, ok := parse.Month(text); ok {
+			key.Month = m
+			key.HasMonth = true
+		}
+	case ModeRandom:
+		if def.Transform != nil {
+			key.Text = def.Transform(text)
 		}
+		key.Hash = def.Random.Sum(key.Text)
 	default:
+		if def.Transform != nil {
+			key.Text = def.Transform(text)
+		}
+		if def.Collation != nil {
+			key.Coll = def.Collation.Key(key.Text)
+		}
 	}
-	return rec
+	return key
+}
+
+// leadingToken returns the first blank-separated token of s, so that a key
+// running to the end of the line (e.g. -k5n) is parsed by its number alone.
+// Only spaces and tabs end it: no-break spaces may group digits ("1 234").
+func leadingToken(s string) string {
+	s = strings.TrimLeftFunc(s, unicode.IsSpace)
+	if i := strings.IndexAny(s, " \t"); i >= 0 {
+		return s[:i]
+	}
+	return s
 }
 
 // BuildRecords creates records.
-func BuildRecords(lines []string, extractor Extractor, cmp Comparator) []Record {
+func BuildRecords(lines []string, cmp Comparator) []Record {
 	out := make([]Record, len(lines))
 	for i, ln := range lines {
-		out[i] = MakeRecord(ln, extractor, cmp)
+		out[i] = MakeRecord(ln, cmp)
 	}
 	return out
 }
 
-// Compare compares records.
+// Compare compares records key by key, then by the full line as a last resort.
+// Stable comparators skip every tie-break, so records with equal keys compare equal.
 func (c *comparator) Compare(a, b Record) int {
-	switch c.mode {
-	case ModeNumeric, ModeHuman:
+	for i, def := range c.keys {
+		cmp := compareKeys(a.Keys[i], b.Keys[i], def, !c.stable)
+		if cmp != 0 {
+			if def.Reverse {
+				return -cmp
+			}
+			return cmp
+		}
+	}
+	if c.stable {
+		return 0
+	}
+	cmp := compareStrings(a.Line, b.Line)
+	if c.reverse {
+		return -cmp
+	}
+	return cmp
+}
+
+func compareKeys(a, b Key, def KeyDef, tieBreak bool) int {
+	switch def.Mode {
+	case ModeNumeric, ModeHuman, ModeDuration:
 		if a.HasNum && b.HasNum {
 			if a.Num < b.Num {
 				return -1
 			} else if a.Num > b.Num {
 				return 1
 			}
-			// tie-break by key then full line for stability
-			if a.Key < b.Key {
-				return -1
-			} else if a.Key > b.Key {
-				return 1
-			}
-			if a.Line < b.Line {
-				return -1
-			} else if a.Line > b.Line {
-				return 1
-			}
-			return 0
+			// tie-break by key text
+			return compareTexts(a, b, tieBreak)
 		}
 		// If one has number and other doesn't, put non-parsed after parsed
 		if a.HasNum && !b.HasNum {
@@ -105,8 +238,8 @@ func (c *comparator) Compare(a, b Record) int {
 		if !a.HasNum && b.HasNum {
 			return 1
 		}
-		// both no numbers -> fallback to lexicographic key, then full line
-		return compareStrings(a.Key, b.Key, a.Line, b.Line)
+		// both no numbers -> fallback to lexicographic key
+		return compareStrings(a.Text, b.Text)
 	case ModeMonth:
 		if a.HasMonth && b.HasMonth {
 			if a.Month < b.Month {
@@ -115,7 +248,7 @@ func (c *comparator) Compare(a, b Record) int {
 				return 1
 			}
 			// tie-breakers
-			return compareStrings(a.Key, b.Key, a.Line, b.Line)
+			return compareTexts(a, b, tieBreak)
 		}
 		if a.HasMonth && !b.HasMonth {
 			return -1
@@ -123,21 +256,80 @@ func (c *comparator) Compare(a, b Record) int {
 		if !a.HasMonth && b.HasMonth {
 			return 1
 		}
-		return compareStrings(a.Key, b.Key, a.Line, b.Line)
+		return compareStrings(a.Text, b.Text)
+	case ModeDate:
+		if a.HasTime && b.HasTime {
+			if cmp := a.Time.Compare(b.Time); cmp != 0 {
+				return cmp
+			}
+			return compareTexts(a, b, tieBreak)
+		}
+		if a.HasTime && !b.HasTime {
+			return -1
+		}
+		if !a.HasTime && b.HasTime {
+			return 1
+		}
+		return compareStrings(a.Text, b.Text)
+	case ModeGeneral:
+		// like GNU sort -g: unparsed first, then NaN, then numbers with ±Inf at the ends
+		if ra, rb := generalRank(a), generalRank(b); ra != rb {
+			return ra - rb
+		}
+		if a.HasNum && a.Num < b.Num {
+			return -1
+		} else if a.HasNum && a.Num > b.Num {
+			return 1
+		}
+		return compareTexts(a, b, tieBreak)
+	case ModeVersion:
+		if cmp := parse.CompareVersions(a.Text, b.Text); cmp != 0 {
+			return cmp
+		}
+		return compareTexts(a, b, tieBreak)
+	case ModeRandom:
+		if a.Hash < b.Hash {
+			return -1
+		} else if a.Hash > b.Hash {
+			return 1
+		}
+		// hash collisions of different keys must not interleave them
+		return compareTexts(a, b, tieBreak)
 	default:
-		return compareStrings(a.Key, b.Key, a.Line, b.Line)
+		if def.Collation == nil {
+			return compareStrings(a.Text, b.Text)
+		}
+		if cmp := compareStrings(a.Coll, b.Coll); cmp != 0 {
+			return cmp
+		}
+		return compareTexts(a, b, tieBreak)
 	}
 }
 
-func compareStrings(ka, kb, la, lb string) int {
-	if ka < kb {
-		return -1
-	} else if ka > kb {
+// compareTexts orders keys that are equal by value by their text,
+// unless tie-breaks are off for a stable sort.
+func compareTexts(a, b Key, tieBreak bool) int {
+	if !tieBreak {
+		return 0
+	}
+	return compareStrings(a.Text, b.Text)
+}
+
+func generalRank(k Key) int {
+	switch {
+	case !k.HasNum:
+		return 0
+	case math.IsNaN(k.Num):
 		return 1
+	default:
+		return 2
 	}
-	if la < lb {
+}
+
+func compareStrings(a, b string) int {
+	if a < b {
 		return -1
-	} else if la > lb {
+	} else if a > b {
 		return 1
 	}
 	return 0
@@ -151,21 +343,37 @@ func Unique(records []Record, cmp Comparator) []Record {
 	out := records[:0]
 	out = append(out, records[0])
 	for i := 1; i < len(records); i++ {
-		prev := out[len(out)-1]
-		cur := records[i]
-		// unique by key equality for the chosen mode
-		eq := false
-		switch cmp.Mode() {
-		case ModeNumeric, ModeHuman:
-			eq = prev.HasNum && cur.HasNum && prev.Num == cur.Num && prev.Key == cur.Key
-		case ModeMonth:
-			eq = prev.HasMonth && cur.HasMonth && prev.Month == cur.Month && prev.Key == cur.Key
-		default:
-			eq = prev.Key == cur.Key
-		}
-		if !eq {
-			out = append(out, cur)
+		if !SameKey(out[len(out)-1], records[i], cmp) {
+			out = append(out, records[i])
 		}
 	}
 	return out
 }
+
+// SameKey reports whether two records have equal keys for the chosen modes.
+func SameKey(a, b Record, cmp Comparator) bool {
+	for i, def := range cmp.Keys() {
+		if !equalKeys(a.Keys[i], b.Keys[i], def.Mode) {
+			return false
+		}
+	}
+	return true
+}
+
+// equalKeys reports whether a and b are the same key; keys that did not
+// parse are the same when their text is.
+func equalKeys(a, b Key, mode Mode) bool {
+	if a.Text != b.Text {
+		return false
+	}
+	switch mode {
+	case ModeNumeric, ModeHuman, ModeGeneral, ModeDuration:
+		return a.HasNum == b.HasNum && (!a.HasNum || a.Num == b.Num)
+	case ModeMonth:
+		return a.HasMonth == b.HasMonth && (!a.HasMonth || a.Month == b.Month)
+	case ModeDate:
+		return a.HasTime == b.HasTime && (!a.HasTime || a.Time.Equal(b.Time))
+	default:
+		return true
+	}
+}
@@ -0,0 +1,155 @@
+package sort
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// KeySpan returns the byte range of the key within line for --debug.
+// ok is false when the key has no position in the line (CSV fields are
+// unquoted and JSON values are looked up, so their text differs from the line).
+func KeySpan(e Extractor, line string) (start, end int, ok bool) {
+	te, isText := e.(*extractor)
+	if !isText {
+		return 0, 0, false
+	}
+	start, end = 0, len(line)
+	if te.spec.StartField > 0 {
+		start, end = te.span(line)
+	}
+	if te.trimRight {
+		end = start + len(strings.TrimRightFunc(line[start:end], unicode.IsSpace))
+	}
+	return start, end, true
+}
+
+// modeNames are the names --debug prints for each mode.
+var modeNames = map[Mode]string{
+	ModeString:   "text",
+	ModeNumeric:  "numeric",
+	ModeHuman:    "human",
+	ModeMonth:    "month",
+	ModeVersion:  "version",
+	ModeGeneral:  "general numeric",
+	ModeRandom:   "random",
+	ModeDuration: "duration",
+	ModeDate:     "date",
+}
+
+// Describe tells how a key was understood: its mode and parsed value.
+func Describe(k Key, def KeyDef) string {
+	name := modeNames[def.Mode]
+	switch def.Mode {
+	case ModeNumeric, ModeHuman, ModeGeneral:
+		if !k.HasNum {
+			return fmt.Sprintf("%s: unparsed %q", name, k.Text)
+		}
+		return fmt.Sprintf("%s: %s", name, formatNum(k.Num))
+	case ModeDuration:
+		if !k.HasNum {
+			return fmt.Sprintf("%s: unparsed %q", name, k.Text)
+		}
+		return fmt.Sprintf("%s: %s", name, time.Duration(k.Num*float64(time.Second)))
+	case ModeMonth:
+		if !k.HasMonth {
+			return fmt.Sprintf("%s: unparsed %q", name, k.Text)
+		}
+		return fmt.Sprintf("%s: %d (%s)", name, k.Month, time.Month(k.Month))
+	case ModeDate:
+		if !k.HasTime {
+			return fmt.Sprintf("%s: unparsed %q", name, k.Text)
+		}
+		return fmt.Sprintf("%s: %s", name, k.Time.Format(time.RFC3339Nano))
+	case ModeRandom:
+		return fmt.Sprintf("%s: %q, hash %016x", name, k.Text, k.Hash)
+	default:
+		if def.Collation != nil {
+			return fmt.Sprintf("%s: %q, by locale", name, k.Text)
+		}
+		return fmt.Sprintf("%s: %q", name, k.Text)
+	}
+}
+
+func formatNum(v float64) string {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return fmt.Sprint(v)
+	}
+	return fmt.Sprintf("%g", v)
+}
+
+// Explain tells which branch of Compare ordered a and b, ending with the
+// outcome: "<" (a first), ">" (b first) or "=".
+func (c *comparator) Explain(a, b Record) string {
+	for i, def := range c.keys {
+		cmp := compareKeys(a.Keys[i], b.Keys[i], def, !c.stable)
+		if cmp != 0 {
+			if def.Reverse {
+				cmp = -cmp
+			}
+			return fmt.Sprintf("key %d, %s: %s", i+1, keyBranch(a.Keys[i], b.Keys[i], def), relation(cmp))
+		}
+	}
+	if c.stable {
+		return "all keys equal, input order kept (-s): ="
+	}
+	cmp := compareStrings(a.Line, b.Line)
+	if c.reverse {
+		cmp = -cmp
+	}
+	if cmp == 0 {
+		return "identical lines: ="
+	}
+	return "all keys equal, last-resort comparison of whole lines: " + relation(cmp)
+}
+
+func relation(cmp int) string {
+	if cmp < 0 {
+		return "<"
+	}
+	return ">"
+}
+
+// keyBranch names the branch of compareKeys that told two unequal keys apart.
+func keyBranch(a, b Key, def KeyDef) string {
+	var hasA, hasB bool
+	switch def.Mode {
+	case ModeNumeric, ModeHuman, ModeDuration, ModeGeneral:
+		hasA, hasB = a.HasNum, b.HasNum
+	case ModeMonth:
+		hasA, hasB = a.HasMonth, b.HasMonth
+	case ModeDate:
+		hasA, hasB = a.HasTime, b.HasTime
+	case ModeVersion:
+		return "version order"
+	case ModeRandom:
+		if a.Hash != b.Hash {
+			return "hash order"
+		}
+		return "hash collision, by key text"
+	default:
+		if def.Collation != nil && a.Coll != b.Coll {
+			return "locale order"
+		}
+		return "byte order of key text"
+	}
+
+	switch {
+	case def.Mode == ModeGeneral && hasA && hasB && math.IsNaN(a.Num) != math.IsNaN(b.Num):
+		return "NaN before numbers"
+	case hasA && hasB && math.IsNaN(a.Num) && math.IsNaN(b.Num):
+		return "equal values, by key text"
+	case hasA && hasB && (a.Num != b.Num || a.Month != b.Month || !a.Time.Equal(b.Time)):
+		return "parsed values"
+	case hasA && hasB:
+		return "equal values, by key text"
+	case hasA != hasB && def.Mode == ModeGeneral:
+		return "unparsed before parsed"
+	case hasA != hasB:
+		return "parsed before unparsed"
+	default:
+		return "both unparsed, by key text"
+	}
+}
@@ -3,6 +3,7 @@ package sort
 import (
 	"strings"
 	"unicode"
+	"unicode/utf8"
 
 	"gosort/internal/options"
 )
@@ -14,24 +15,45 @@ type Extractor interface {
 }
 
 type extractor struct {
-	keyColumn int
+	spec      options.KeySpec
+	field     fieldFunc
 	trimRight bool
 }
 
-// NewExtractor creates a new Extractor.
-func NewExtractor(opt options.Options) Extractor {
+// fieldFunc returns the byte range of a 1-based field of s.
+// If not present, returns an empty range at the end of s.
+type fieldFunc func(s string, field int) (int, int)
+
+// NewExtractor creates a new Extractor for a single key definition.
+// Text fields are split by opt.Separator, or by runs of blanks when it is empty;
+// --format=csv and --format=jsonl take keys from CSV fields and JSON paths.
+func NewExtractor(spec options.KeySpec, opt options.Options) Extractor {
+	switch opt.Format {
+	case options.FormatCSV:
+		comma := opt.Separator
+		if comma == "" {
+			comma = ","
+		}
+		return newCSVExtractor(spec, comma)
+	case options.FormatJSONL:
+		return newJSONExtractor(spec)
+	}
+	field := blankField
+	if opt.Separator != "" {
+		field = sepField(opt.Separator)
+	}
 	return &extractor{
-		keyColumn: opt.KeyColumn,
-		trimRight: opt.IgnoreTrailingBlanks,
+		spec:      spec,
+		field:     field,
+		trimRight: spec.IgnoreTrailingBlanks,
 	}
 }
 
 func (e *extractor) Key(line string) string {
-	var key string
-	if e.keyColumn <= 0 {
-		key = line
-	} else {
-		key = getTabColumn(line, e.keyColumn)
+	key := line
+	if e.spec.StartField > 0 {
+		start, end := e.span(line)
+		key = line[start:end]
 	}
 	if e.trimRight {
 		key = strings.TrimRightFunc(key, unicode.IsSpace)
@@ -47,31 +69,90 @@ func (e *extractor) EqualKey(a, b string) bool {
 	return a == b
 }
 
-// getTabColumn returns the 1-based column split by tabs.
-// If not present, returns empty string.
-func getTabColumn(s string, col int) string {
-	if col <= 1 {
-		// first column is chars before first '\t' or whole line
-		i := strings.IndexByte(s, '\t')
-		if i == -1 {
-			return s
+// span returns the byte range of the key within line.
+// Positions past the end of a field are clamped to it.
+func (e *extractor) span(s string) (int, int) {
+	fs, fe := e.field(s, e.spec.StartField)
+	if e.spec.IgnoreLeadingBlanks {
+		fs = skipBlanks(s, fs, fe)
+	}
+	start := advanceChars(s, fs, fe, e.spec.StartChar-1)
+
+	end := len(s)
+	if e.spec.EndField > 0 {
+		fs, fe = e.field(s, e.spec.EndField)
+		end = fe
+		if e.spec.EndChar > 0 {
+			if e.spec.IgnoreLeadingBlanks {
+				fs = skipBlanks(s, fs, fe)
+			}
+			end = advanceChars(s, fs, fe, e.spec.EndChar)
 		}
-		return s[:i]
 	}
-	i := 0
-	start := 0
-	for idx := 0; idx < len(s); idx++ {
-		if s[idx] == '\t' {
-			i++
-			if i == col-1 {
-				start = idx + 1
-			} else if i == col {
-				return s[start:idx]
+	if end < start {
+		end = start
+	}
+	return start, end
+}
+
+// sepField splits fields by an explicit separator, which may be several bytes long.
+func sepField(sep string) fieldFunc {
+	return func(s string, field int) (int, int) {
+		start := 0
+		for i := 1; i < field; i++ {
+			idx := strings.Index(s[start:], sep)
+			if idx == -1 {
+				return len(s), len(s)
 			}
+			start += idx + len(sep)
 		}
+		end := strings.Index(s[start:], sep)
+		if end == -1 {
+			return start, len(s)
+		}
+		return start, start + end
 	}
-	if i == col-1 {
-		return s[start:]
+}
+
+// blankField splits fields at every transition from a non-blank to a blank,
+// like GNU sort without -t: each field keeps the blanks that precede it.
+func blankField(s string, field int) (int, int) {
+	start := 0
+	for i := 1; ; i++ {
+		end := start
+		for end < len(s) && isBlank(s[end]) {
+			end++
+		}
+		for end < len(s) && !isBlank(s[end]) {
+			end++
+		}
+		if i == field {
+			return start, end
+		}
+		if end == len(s) {
+			return len(s), len(s)
+		}
+		start = end
+	}
+}
+
+func isBlank(c byte) bool {
+	return c == ' ' || c == '\t'
+}
+
+// skipBlanks moves pos past blanks without passing limit.
+func skipBlanks(s string, pos, limit int) int {
+	for pos < limit && isBlank(s[pos]) {
+		pos++
+	}
+	return pos
+}
+
+// advanceChars moves n characters forward from pos without passing limit.
+func advanceChars(s string, pos, limit, n int) int {
+	for ; n > 0 && pos < limit; n-- {
+		_, size := utf8.DecodeRuneInString(s[pos:limit])
+		pos += size
 	}
-	return ""
+	return pos
 }
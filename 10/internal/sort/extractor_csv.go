@@ -0,0 +1,106 @@
+package sort
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"gosort/internal/options"
+)
+
+// csvExtractor takes keys from RFC 4180 fields: quotes are removed and
+// character offsets count within the unquoted values.
+type csvExtractor struct {
+	spec      options.KeySpec
+	comma     string
+	trimRight bool
+}
+
+func newCSVExtractor(spec options.KeySpec, comma string) Extractor {
+	return &csvExtractor{
+		spec:      spec,
+		comma:     comma,
+		trimRight: spec.IgnoreTrailingBlanks,
+	}
+}
+
+func (e *csvExtractor) Key(line string) string {
+	if e.spec.StartField == 0 {
+		return e.trim(line)
+	}
+	fields := SplitCSV(line, e.comma)
+	first, last := e.spec.StartField-1, len(fields)-1
+	lastChar := 0
+	if e.spec.EndField > 0 {
+		last, lastChar = e.spec.EndField-1, e.spec.EndChar
+	}
+	if first >= len(fields) || last < first {
+		return ""
+	}
+	last = min(last, len(fields)-1)
+
+	parts := make([]string, 0, last-first+1)
+	for i := first; i <= last; i++ {
+		f := fields[i]
+		if i == last && lastChar > 0 {
+			f = f[:advanceChars(f, 0, len(f), lastChar)]
+		}
+		if i == first {
+			if e.spec.IgnoreLeadingBlanks {
+				f = strings.TrimLeft(f, " \t")
+			}
+			f = f[advanceChars(f, 0, len(f), e.spec.StartChar-1):]
+		}
+		parts = append(parts, f)
+	}
+	return e.trim(strings.Join(parts, e.comma))
+}
+
+func (e *csvExtractor) EqualKey(a, b string) bool {
+	return e.trim(a) == e.trim(b)
+}
+
+func (e *csvExtractor) trim(s string) string {
+	if e.trimRight {
+		return strings.TrimRightFunc(s, unicode.IsSpace)
+	}
+	return s
+}
+
+// SplitCSV splits an RFC 4180 record into unquoted fields.
+// Quoted fields may contain the separator, doubled quotes and newlines.
+func SplitCSV(record, comma string) []string {
+	var (
+		fields []string
+		field  strings.Builder
+	)
+	for i := 0; ; {
+		field.Reset()
+		if i < len(record) && record[i] == '"' {
+			// quoted field: read up to the closing quote, then up to the separator
+			i++
+			for i < len(record) {
+				if record[i] == '"' {
+					if i+1 < len(record) && record[i+1] == '"' {
+						field.WriteByte('"')
+						i += 2
+						continue
+					}
+					i++
+					break
+				}
+				_, size := utf8.DecodeRuneInString(record[i:])
+				field.WriteString(record[i : i+size])
+				i += size
+			}
+		}
+		end := strings.Index(record[i:], comma)
+		if end == -1 {
+			field.WriteString(record[i:])
+			return append(fields, field.String())
+		}
+		field.WriteString(record[i : i+end])
+		fields = append(fields, field.String())
+		i += end + len(comma)
+	}
+}
@@ -0,0 +1,115 @@
+package sort
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"gosort/internal/options"
+)
+
+// jsonExtractor takes keys from JSON Lines by a path such as .request.duration
+// or .items[0].id. Strings yield their value, other values their JSON text,
+// and a missing path an empty key.
+type jsonExtractor struct {
+	path      []string
+	trimLeft  bool
+	trimRight bool
+}
+
+func newJSONExtractor(spec options.KeySpec) Extractor {
+	return &jsonExtractor{
+		path:      splitJSONPath(spec.Path),
+		trimLeft:  spec.IgnoreLeadingBlanks,
+		trimRight: spec.IgnoreTrailingBlanks,
+	}
+}
+
+// splitJSONPath turns ".a.b[0]" into ["a", "b", "0"].
+func splitJSONPath(path string) []string {
+	path = strings.ReplaceAll(path, "[", ".")
+	path = strings.ReplaceAll(path, "]", "")
+	var out []string
+	for _, seg := range strings.Split(path, ".") {
+		if seg != "" {
+			out = append(out, seg)
+		}
+	}
+	return out
+}
+
+func (e *jsonExtractor) Key(line string) string {
+	if e.path == nil {
+		return e.trim(line)
+	}
+	raw := json.RawMessage(line)
+	for _, seg := range e.path {
+		next, ok := lookupJSON(raw, seg)
+		if !ok {
+			return ""
+		}
+		raw = next
+	}
+	return e.trim(jsonText(raw))
+}
+
+func (e *jsonExtractor) EqualKey(a, b string) bool {
+	return e.trim(a) == e.trim(b)
+}
+
+func (e *jsonExtractor) trim(s string) string {
+	if e.trimLeft {
+		s = strings.TrimLeft(s, " \t")
+	}
+	if e.trimRight {
+		s = strings.TrimRightFunc(s, unicode.IsSpace)
+	}
+	return s
+}
+
+// lookupJSON returns a member of an object or an element of an array.
+func lookupJSON(raw json.RawMessage, seg string) (json.RawMessage, bool) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return nil, false
+	}
+	switch raw[0] {
+	case '{':
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &obj); err != nil {
+			return nil, false
+		}
+		v, ok := obj[seg]
+		return v, ok
+	case '[':
+		idx, err := strconv.Atoi(seg)
+		if err != nil {
+			return nil, false
+		}
+		var arr []json.RawMessage
+		if err := json.Unmarshal(raw, &arr); err != nil || idx < 0 || idx >= len(arr) {
+			return nil, false
+		}
+		return arr[idx], true
+	default:
+		return nil, false
+	}
+}
+
+// jsonText returns a string's value, or the compact JSON text of anything else.
+func jsonText(raw json.RawMessage) string {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) > 0 && raw[0] == '"' {
+		var s string
+		if err := json.Unmarshal(raw, &s); err == nil {
+			return s
+		}
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, raw); err != nil {
+		return string(raw)
+	}
+	return buf.String()
+}
@@ -0,0 +1,29 @@
+package sort
+
+import "hash/fnv"
+
+// RandomHash hashes keys with a seed, so that -R orders distinct keys randomly
+// while equal keys stay together and the same seed gives the same order.
+type RandomHash struct {
+	seed []byte
+}
+
+// NewRandomHash creates a RandomHash keyed by seed.
+func NewRandomHash(seed []byte) *RandomHash {
+	return &RandomHash{seed: seed}
+}
+
+// Sum returns the keyed hash of s.
+func (h *RandomHash) Sum(s string) uint64 {
+	f := fnv.New64a()
+	_, _ = f.Write(h.seed)
+	_, _ = f.Write([]byte(s))
+	// splitmix64 finalizer: FNV alone leaves similar keys close together
+	x := f.Sum64()
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
@@ -1,14 +1,24 @@
 package sort
 
-// Record contains a line with some specs
+import "time"
+
+// Record contains a line with its parsed sort keys
 type Record struct {
-	Line     string
-	Key      string
+	Line string
+	Keys []Key // one per key definition of the comparator
+}
+
+// Key contains a key extracted from a line with some specs
+type Key struct {
+	Text     string
+	Coll     string // locale sort key, set only with a collation
 	Num      float64
 	HasNum   bool
 	Month    int
 	HasMonth bool
-	SortMode Mode
+	Time     time.Time // parsed timestamp for ModeDate
+	HasTime  bool
+	Hash     uint64 // keyed hash for ModeRandom
 }
 
 // Mode is taken as a separate type to avoid errors
@@ -23,4 +33,14 @@ const (
 	ModeHuman
 	// ModeMonth means "parse months"
 	ModeMonth
+	// ModeVersion means "compare version numbers within text"
+	ModeVersion
+	// ModeGeneral means "consider lines floats, with exponents, infinities and NaN"
+	ModeGeneral
+	// ModeRandom means "shuffle by a keyed hash, keeping equal keys together"
+	ModeRandom
+	// ModeDuration means "consider lines durations such as 1h30m or 250ms"
+	ModeDuration
+	// ModeDate means "parse timestamps with a layout or common formats"
+	ModeDate
 )
@@ -0,0 +1,81 @@
+package writer
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Output is where sorted lines go.
+type Output interface {
+	io.Writer
+	// Commit makes the written data visible; call it once everything is written.
+	Commit() error
+	// Abort discards the written data if Commit was not called.
+	Abort()
+}
+
+// Open returns stdout for an empty path, otherwise an atomic file output.
+func Open(path string) (Output, error) {
+	if path == "" {
+		return stdout{}, nil
+	}
+	return createAtomic(path)
+}
+
+type stdout struct{}
+
+func (stdout) Write(p []byte) (int, error) { return os.Stdout.Write(p) }
+func (stdout) Commit() error               { return nil }
+func (stdout) Abort()                      {}
+
+// atomicFile writes to a temporary file next to path and renames it into place
+// on Commit, so the output may safely name one of the inputs.
+type atomicFile struct {
+	f    *os.File
+	path string
+	done bool
+}
+
+func createAtomic(path string) (*atomicFile, error) {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".gosort-*")
+	if err != nil {
+		return nil, fmt.Errorf("create output: %w", err)
+	}
+	// keep the permissions of a file being replaced
+	mode := os.FileMode(0o644)
+	if st, err := os.Stat(path); err == nil {
+		mode = st.Mode().Perm()
+	}
+	if err := f.Chmod(mode); err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		return nil, fmt.Errorf("create output: %w", err)
+	}
+	return &atomicFile{f: f, path: path}, nil
+}
+
+func (a *atomicFile) Write(p []byte) (int, error) { return a.f.Write(p) }
+
+func (a *atomicFile) Commit() error {
+	a.done = true
+	if err := a.f.Close(); err != nil {
+		_ = os.Remove(a.f.Name())
+		return fmt.Errorf("close output: %w", err)
+	}
+	if err := os.Rename(a.f.Name(), a.path); err != nil {
+		_ = os.Remove(a.f.Name())
+		return fmt.Errorf("rename output: %w", err)
+	}
+	return nil
+}
+
+func (a *atomicFile) Abort() {
+	if a.done {
+		return
+	}
+	a.done = true
+	_ = a.f.Close()
+	_ = os.Remove(a.f.Name())
+}
@@ -0,0 +1,157 @@
+// Package gosort exposes the ordering rules of the gosort utility to Go programs:
+// sorting and checking streams of lines, and comparing single strings.
+//
+//	opts := gosort.Options{Keys: []gosort.KeySpec{gosort.Field(2).Numeric().Reverse().MustSpec()}}
+//	err := gosort.Sort(os.Stdin, os.Stdout, opts)
+package gosort
+
+import (
+	"fmt"
+	"io"
+	stdsort "sort"
+
+	"gosort/internal/options"
+	"gosort/internal/run"
+	isort "gosort/internal/sort"
+)
+
+// Options configures sorting; the zero value sorts whole lines in byte order.
+// Fields mirror the command-line flags (see the gosort README).
+// Options.Random uses Options.RandomSeed as is, so a nil seed shuffles the same way every time.
+type Options = options.Options
+
+// KeySpec is one sort key; build it with Field or JSONPath.
+type KeySpec = options.KeySpec
+
+// Format is the structure of input records.
+type Format = options.Format
+
+// Input formats.
+const (
+	FormatText  = options.FormatText
+	FormatCSV   = options.FormatCSV
+	FormatJSONL = options.FormatJSONL
+)
+
+// HumanBase selects what human-readable suffixes multiply by.
+type HumanBase = options.HumanBase
+
+// Bases of human-readable numbers.
+const (
+	HumanIEC  = options.HumanIEC
+	HumanSI   = options.HumanSI
+	HumanAuto = options.HumanAuto
+)
+
+// ErrDisorder is matched by the errors of Check and CheckAll for unsorted input.
+var ErrDisorder = run.ErrDisorder
+
+// DisorderError reports a line that sorts before the line preceding it.
+type DisorderError struct {
+	Line int    // 1-based line number
+	Text string // the line itself
+}
+
+func (e *DisorderError) Error() string {
+	return fmt.Sprintf("%d: disorder: %s", e.Line, e.Text)
+}
+
+// Unwrap makes errors.Is(err, ErrDisorder) hold.
+func (e *DisorderError) Unwrap() error { return ErrDisorder }
+
+// Sort reads lines from r and writes them to w in sorted order.
+func Sort(r io.Reader, w io.Writer, opts Options) error {
+	return SortReaders([]io.Reader{r}, w, opts)
+}
+
+// SortReaders sorts the lines of several inputs together, as if they were
+// concatenated; with opts.Merge the inputs must be sorted already.
+func SortReaders(rs []io.Reader, w io.Writer, opts Options) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	return run.Sort(opts, rs, w)
+}
+
+// Check reports whether r is sorted. It returns nil if it is,
+// and a *DisorderError for the first line out of order if it is not.
+func Check(r io.Reader, opts Options) error {
+	var first *DisorderError
+	err := CheckAll(r, opts, func(d DisorderError) bool {
+		first = &d
+		return false
+	})
+	if first != nil {
+		return first
+	}
+	return err
+}
+
+// CheckAll calls report for every line of r that is out of order;
+// report returns false to stop early. Returns an error matching ErrDisorder
+// if any line was out of order.
+func CheckAll(r io.Reader, opts Options, report func(DisorderError) bool) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	return run.Check(opts, r, func(lineNo int, line string) bool {
+		return report(DisorderError{Line: lineNo, Text: line})
+	})
+}
+
+// Comparator orders single lines by Options, as Sort would.
+type Comparator struct {
+	cmp isort.Comparator
+}
+
+// NewComparator creates a Comparator.
+func NewComparator(opts Options) (*Comparator, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	cmp, err := isort.NewComparator(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Comparator{cmp: cmp}, nil
+}
+
+// Compare returns -1, 0 or +1 as a sorts before, with or after b.
+func (c *Comparator) Compare(a, b string) int {
+	return c.cmp.Compare(isort.MakeRecord(a, c.cmp), isort.MakeRecord(b, c.cmp))
+}
+
+// Less reports whether a sorts before b.
+func (c *Comparator) Less(a, b string) bool {
+	return c.Compare(a, b) < 0
+}
+
+// Strings sorts lines in place. Keys are parsed once per line, not once per comparison.
+func (c *Comparator) Strings(lines []string) {
+	records := isort.BuildRecords(lines, c.cmp)
+	stdsort.SliceStable(records, func(i, j int) bool {
+		return c.cmp.Compare(records[i], records[j]) < 0
+	})
+	for i, rec := range records {
+		lines[i] = rec.Line
+	}
+}
+
+// Less returns a less function for sort.Slice and friends over lines.
+func Less(lines []string, opts Options) (func(i, j int) bool, error) {
+	c, err := NewComparator(opts)
+	if err != nil {
+		return nil, err
+	}
+	return func(i, j int) bool { return c.Less(lines[i], lines[j]) }, nil
+}
+
+// Strings sorts lines in place by opts.
+func Strings(lines []string, opts Options) error {
+	c, err := NewComparator(opts)
+	if err != nil {
+		return err
+	}
+	c.Strings(lines)
+	return nil
+}
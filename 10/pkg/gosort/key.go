@@ -0,0 +1,133 @@
+package gosort
+
+import (
+	"fmt"
+
+	"gosort/internal/options"
+	"gosort/internal/parse"
+)
+
+// KeyBuilder builds a KeySpec step by step:
+//
+//	gosort.Field(3).To(3).Numeric().Reverse().Spec() // -k3,3nr
+//	gosort.JSONPath(".request.duration").Numeric().Spec() // -k .request.duration:n
+//
+// Every method returns a modified copy, so a builder may be reused as a template.
+type KeyBuilder struct {
+	spec options.KeySpec
+	err  error
+}
+
+// Field starts a key at the 1-based field n, running to the end of the line.
+func Field(n int) KeyBuilder {
+	b := KeyBuilder{spec: options.KeySpec{StartField: n, StartChar: 1}}
+	if n < 1 {
+		b.err = fmt.Errorf("invalid key: field number %d", n)
+	}
+	return b
+}
+
+// JSONPath starts a key for FormatJSONL, such as ".request.duration" or ".items[0].id".
+func JSONPath(path string) KeyBuilder {
+	b := KeyBuilder{spec: options.KeySpec{Path: path}}
+	if len(path) == 0 || path[0] != '.' {
+		b.err = fmt.Errorf("invalid key %q: JSON path must start with '.'", path)
+	}
+	return b
+}
+
+// ParseKey parses a key in the command-line syntax, "2,2n" or ".a.b:n" for JSON Lines.
+func ParseKey(s string, format Format) (KeySpec, error) {
+	if format == FormatJSONL {
+		return options.ParseJSONKey(s)
+	}
+	return options.ParseKey(s)
+}
+
+// DateLayout resolves a --date-sort argument into Options.DateLayout: "auto"
+// means auto-detection, rfc3339, syslog, iso and date are shortcuts,
+// anything else is a Go time layout. Returns false for a layout without
+// any date or time element.
+func DateLayout(name string) (string, bool) {
+	return parse.DateLayout(name)
+}
+
+// Char moves the start of the key to the 1-based character c of its start field.
+func (b KeyBuilder) Char(c int) KeyBuilder {
+	b.spec.StartChar = c
+	return b
+}
+
+// To ends the key at the end of field n instead of the end of the line.
+func (b KeyBuilder) To(n int) KeyBuilder {
+	b.spec.EndField = n
+	return b
+}
+
+// ToChar ends the key at the 1-based character c of its end field.
+func (b KeyBuilder) ToChar(c int) KeyBuilder {
+	b.spec.EndChar = c
+	return b
+}
+
+// Numeric compares the key by numeric value (n).
+func (b KeyBuilder) Numeric() KeyBuilder { b.spec.Numeric = true; return b }
+
+// HumanNumeric compares human-readable sizes such as 2K or 1.5G (h).
+func (b KeyBuilder) HumanNumeric() KeyBuilder { b.spec.HumanNumeric = true; return b }
+
+// Month compares month names (M).
+func (b KeyBuilder) Month() KeyBuilder { b.spec.Month = true; return b }
+
+// Version compares version numbers within text (V).
+func (b KeyBuilder) Version() KeyBuilder { b.spec.Version = true; return b }
+
+// GeneralNumeric compares floats with exponents, infinities and NaN (g).
+func (b KeyBuilder) GeneralNumeric() KeyBuilder { b.spec.GeneralNumeric = true; return b }
+
+// Random shuffles by a keyed hash of the key (R).
+func (b KeyBuilder) Random() KeyBuilder { b.spec.Random = true; return b }
+
+// Duration compares durations such as 1h30m (D).
+func (b KeyBuilder) Duration() KeyBuilder { b.spec.Duration = true; return b }
+
+// Date compares timestamps in Options.DateLayout (T).
+func (b KeyBuilder) Date() KeyBuilder { b.spec.Date = true; return b }
+
+// Reverse reverses the order of the key (r).
+func (b KeyBuilder) Reverse() KeyBuilder { b.spec.Reverse = true; return b }
+
+// IgnoreLeadingBlanks ignores blanks at the start of the key (b).
+func (b KeyBuilder) IgnoreLeadingBlanks() KeyBuilder { b.spec.IgnoreLeadingBlanks = true; return b }
+
+// IgnoreTrailingBlanks ignores blanks at the end of the key, as -b does.
+func (b KeyBuilder) IgnoreTrailingBlanks() KeyBuilder { b.spec.IgnoreTrailingBlanks = true; return b }
+
+// FoldCase compares lower case as upper case (f).
+func (b KeyBuilder) FoldCase() KeyBuilder { b.spec.FoldCase = true; return b }
+
+// Dictionary considers only blanks, letters and digits (d).
+func (b KeyBuilder) Dictionary() KeyBuilder { b.spec.Dictionary = true; return b }
+
+// IgnoreNonPrinting considers only printable characters (i).
+func (b KeyBuilder) IgnoreNonPrinting() KeyBuilder { b.spec.IgnoreNonPrinting = true; return b }
+
+// Spec returns the key, or an error for invalid positions and conflicting modes.
+func (b KeyBuilder) Spec() (KeySpec, error) {
+	if b.err != nil {
+		return KeySpec{}, b.err
+	}
+	if err := b.spec.Validate(); err != nil {
+		return KeySpec{}, fmt.Errorf("invalid key: %w", err)
+	}
+	return b.spec, nil
+}
+
+// MustSpec is like Spec but panics on error; it suits keys fixed at compile time.
+func (b KeyBuilder) MustSpec() KeySpec {
+	spec, err := b.Spec()
+	if err != nil {
+		panic(err)
+	}
+	return spec
+}
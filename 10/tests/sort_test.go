@@ -2,9 +2,17 @@ package tests
 
 import (
 	"bytes"
+	"compress/gzip"
+	"errors"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"testing"
+
+	"gosort/pkg/gosort"
 )
 
 const prog = "../gosort"
@@ -48,6 +56,84 @@ func TestCLI(t *testing.T) {
 			input:       "3\tz\n1\ta\n2\tb",
 			expectedOut: "1\ta\n2\tb\n3\tz",
 		},
+		{
+			name:        "Несколько ключей с модификаторами (-k3,3nr -k1,1M)",
+			args:        []string{"-k3,3nr", "-k1,1M"},
+			input:       "Feb\t10\t5\nMar\t10\t7\nJan\t9\t5\nJan\t10\t7",
+			expectedOut: "Jan\t10\t7\nMar\t10\t7\nJan\t9\t5\nFeb\t10\t5",
+		},
+		{
+			name:        "Смещения символов в ключе (-k 1.2,1.3)",
+			args:        []string{"-k", "1.2,1.3"},
+			input:       "xc1\nya2\nzb3",
+			expectedOut: "ya2\nzb3\nxc1",
+		},
+		{
+			name:      "Неверное описание ключа",
+			args:      []string{"-k", "1x"},
+			input:     "a",
+			expectErr: true,
+		},
+		{
+			name:        "Поля по умолчанию разделяются пробелами (-k2n)",
+			args:        []string{"-k2n"},
+			input:       "x  10 b\ny 9 a\nz   100 c",
+			expectedOut: "y 9 a\nx  10 b\nz   100 c",
+		},
+		{
+			name:        "Многобайтовый разделитель (-t)",
+			args:        []string{"-t", "│", "-k2,2n"},
+			input:       "a│3\nb│1\nc│2",
+			expectedOut: "b│1\nc│2\na│3",
+		},
+		{
+			name:        "Без учёта регистра (-f)",
+			args:        []string{"-f"},
+			input:       "b\nA\na\nB",
+			expectedOut: "A\na\nB\nb",
+		},
+		{
+			name:        "Словарный порядок (-d)",
+			args:        []string{"-d"},
+			input:       "#c\n_b\na",
+			expectedOut: "a\n_b\n#c",
+		},
+		{
+			name:        "Сортировка с учётом локали (--locale)",
+			args:        []string{"--locale=ru_RU.UTF-8"},
+			input:       "яблоко\nЁж\nарбуз\nЖук",
+			expectedOut: "арбуз\nЁж\nЖук\nяблоко",
+		},
+		{
+			name:        "Сортировка версий (-V)",
+			args:        []string{"-V"},
+			input:       "v1.10.0\nv1.9.2\nv1.0\nv1.0~rc1\nv1.9.10",
+			expectedOut: "v1.0~rc1\nv1.0\nv1.9.2\nv1.9.10\nv1.10.0",
+		},
+		{
+			name:        "Общая числовая сортировка (-g)",
+			args:        []string{"-g"},
+			input:       "1e-3\ninf\nnan\n-inf\n2\nabc\n0x10",
+			expectedOut: "abc\nnan\n-inf\n1e-3\n2\n0x10\ninf",
+		},
+		{
+			name:        "Стабильная сортировка (-s)",
+			args:        []string{"-s", "-k2,2n"},
+			input:       "b 2\na 1\nc 2\na 2\nb 1",
+			expectedOut: "a 1\nb 1\nb 2\nc 2\na 2",
+		},
+		{
+			name:        "CSV с кавычками и заголовком (--format=csv)",
+			args:        []string{"--format=csv", "-k2,2n"},
+			input:       "name,size,note\nb,10,\"x, y\"\na,9,\"multi\nline\"\nc,100,\"say \"\"hi\"\"\"",
+			expectedOut: "name,size,note\na,9,\"multi\nline\"\nb,10,\"x, y\"\nc,100,\"say \"\"hi\"\"\"",
+		},
+		{
+			name:        "JSON Lines по пути ключа (--format=jsonl)",
+			args:        []string{"--format=jsonl", "-k", ".request.duration:n"},
+			input:       "{\"request\":{\"duration\":12.5}}\n{\"id\":\"c\"}\n{\"request\":{\"duration\":3}}",
+			expectedOut: "{\"request\":{\"duration\":3}}\n{\"request\":{\"duration\":12.5}}\n{\"id\":\"c\"}",
+		},
 		{
 			name:        "Числовая сортировка (-n)",
 			args:        []string{"-n"},
@@ -66,6 +152,90 @@ func TestCLI(t *testing.T) {
 			input:       "a\na\nb\nb\nc",
 			expectedOut: "a\nb\nc",
 		},
+		{
+			name:        "Подсчёт повторов (--count)",
+			args:        []string{"--count"},
+			input:       "b\na\nb\nc\nb\na",
+			expectedOut: "      2 a\n      3 b\n      1 c",
+		},
+		{
+			name:        "Только повторяющиеся ключи (--repeated -k2,2n)",
+			args:        []string{"--repeated", "-k2,2n"},
+			input:       "x 1\ny 2\nz 2\nw 3\nv 3",
+			expectedOut: "y 2\nv 3",
+		},
+		{
+			name:        "Только уникальные ключи (--unique-only -f)",
+			args:        []string{"--unique-only", "-f"},
+			input:       "B\na\nb\nc\nA\nd",
+			expectedOut: "c\nd",
+		},
+		{
+			name:        "Одинаковые нечисловые ключи группируются (-n --count)",
+			args:        []string{"-n", "--count"},
+			input:       "x\n1\nx\n1",
+			expectedOut: "      2 1\n      2 x",
+		},
+		{
+			name:        "Одинаковые нераспознанные ключи повторяются (-M --repeated)",
+			args:        []string{"-M", "--repeated"},
+			input:       "foo\nJan\nfoo\nbar",
+			expectedOut: "foo",
+		},
+		{
+			name:        "Нераспознанные ключи с разным текстом уникальны (-k1,1g --unique-only)",
+			args:        []string{"-k1,1g", "--unique-only"},
+			input:       "x\ny\nx\n2",
+			expectedOut: "y\n2",
+		},
+		{
+			name:        "Модификатор b пропускает начальные пробелы ключа (-k2b)",
+			args:        []string{"-k2b"},
+			input:       "x  b\nx a",
+			expectedOut: "x a\nx  b",
+		},
+		{
+			name:        "Модификатор b и смещение символа (-k2.2b)",
+			args:        []string{"-k2.2b"},
+			input:       "x  ab\nx ba",
+			expectedOut: "x ba\nx  ab",
+		},
+		{
+			name:      "Несовместимые --repeated и --unique-only",
+			args:      []string{"--repeated", "--unique-only"},
+			input:     "a",
+			expectErr: true,
+		},
+		{
+			name:        "Первые N строк без полной сортировки (--top -u -k2,2nr)",
+			args:        []string{"--top=2", "-u", "-k2,2nr"},
+			input:       "a 5\nb 9\nc 1\nd 9\ne 7",
+			expectedOut: "b 9\ne 7",
+		},
+		{
+			name:        "Последние N строк (--bottom -r)",
+			args:        []string{"--bottom=2", "-r"},
+			input:       "b\nd\na\nc\ne",
+			expectedOut: "b\na",
+		},
+		{
+			name:        "Отладочные пометки ключей (--debug)",
+			args:        []string{"--debug", "-k2,2n"},
+			input:       "a 10\nb x\nc 2",
+			expectedOut: "c 2\n __\nkey 1: numeric: 2\na 10\n ___\nkey 1: numeric: 10\nvs previous: key 1, parsed values: <\nb x\n __\nkey 1: numeric: unparsed \" x\"\nvs previous: key 1, parsed before unparsed: <",
+		},
+		{
+			name:        "Отладочные пометки нечисловых ключей (--debug -g)",
+			args:        []string{"--debug", "-g"},
+			input:       "b x\na y",
+			expectedOut: "a y\n___\nkey 1: general numeric: unparsed \"a y\"\nb x\n___\nkey 1: general numeric: unparsed \"b x\"\nvs previous: key 1, both unparsed, by key text: <",
+		},
+		{
+			name:        "Отладочные пометки ключей NaN (--debug -g)",
+			args:        []string{"--debug", "-g"},
+			input:       "nan b\nnan a",
+			expectedOut: "nan a\n_____\nkey 1: general numeric: NaN\nnan b\n_____\nkey 1: general numeric: NaN\nvs previous: key 1, equal values, by key text: <",
+		},
 		{
 			name:        "Сортировка по месяцам (-M)",
 			args:        []string{"-M"},
@@ -84,6 +254,72 @@ func TestCLI(t *testing.T) {
 			input:       "2K\n1M\n512\n3K",
 			expectedOut: "512\n2K\n3K\n1M",
 		},
+		{
+			name:        "Десятичные и двоичные приставки (--human-base=auto)",
+			args:        []string{"-h", "--human-base=auto"},
+			input:       "1KiB\n1kB\n1K\n1000\n999",
+			expectedOut: "999\n1000\n1kB\n1K\n1KiB",
+		},
+		{
+			name:        "Дробные приставки СИ (--human-base=si)",
+			args:        []string{"-h", "--human-base=si"},
+			input:       "1k\n250m\n3u\n2",
+			expectedOut: "3u\n250m\n2\n1k",
+		},
+		{
+			name:        "Разделители разрядов (-n)",
+			args:        []string{"-n"},
+			input:       "1,234,567.8\n999\n1_000\n12'000",
+			expectedOut: "999\n1_000\n12'000\n1,234,567.8",
+		},
+		{
+			name:        "Десятичная запятая по локали (-n --locale=ru)",
+			args:        []string{"-n", "--locale=ru"},
+			input:       "1.234,5\n2,5\n10",
+			expectedOut: "2,5\n10\n1.234,5",
+		},
+		{
+			name:        "Длительности (-k2,2D)",
+			args:        []string{"-k2,2D"},
+			input:       "a 1h30m\nb 250ms\nc 90s\nd n/a",
+			expectedOut: "b 250ms\nc 90s\na 1h30m\nd n/a",
+		},
+		{
+			name:        "Даты с автоопределением формата (--date-sort)",
+			args:        []string{"--date-sort"},
+			input:       "2024-03-01T10:00:00+03:00 c\n2024-03-01 06:59:59 b\nnot a date\n2023-12-31 a",
+			expectedOut: "2023-12-31 a\n2024-03-01 06:59:59 b\n2024-03-01T10:00:00+03:00 c\nnot a date",
+		},
+		{
+			name:        "Даты syslog в ключе (-k1,3T)",
+			args:        []string{"-k1,3T"},
+			input:       "Oct 18 12:01:02 host b\nOct  8 23:00:00 host a\nSep 30 00:00:00 host c",
+			expectedOut: "Sep 30 00:00:00 host c\nOct  8 23:00:00 host a\nOct 18 12:01:02 host b",
+		},
+		{
+			name:        "Собственный формат даты (--date-sort=LAYOUT)",
+			args:        []string{"--date-sort=02.01.2006"},
+			input:       "01.02.2024\n31.12.2023\n15.01.2024",
+			expectedOut: "31.12.2023\n15.01.2024\n01.02.2024",
+		},
+		{
+			name:        "Русские названия месяцев (-M)",
+			args:        []string{"-M"},
+			input:       "5 марта\nянварь\nдек\nFebruary",
+			expectedOut: "январь\nFebruary\n5 марта\nдек",
+		},
+		{
+			name:      "Формат даты без элементов даты (--date-sort)",
+			args:      []string{"--date-sort=abc"},
+			input:     "1",
+			expectErr: true,
+		},
+		{
+			name:      "Неизвестное основание (--human-base)",
+			args:      []string{"-h", "--human-base=dec"},
+			input:     "1K",
+			expectErr: true,
+		},
 	}
 
 	for _, tc := range tests {
@@ -124,3 +360,288 @@ func TestCheckSorted(t *testing.T) {
 		t.Errorf("\n-c: Ожидалась ошибка при неотсортированных данных, stderr=%q", errOut)
 	}
 }
+
+func TestExternalSort(t *testing.T) {
+	var b strings.Builder
+	for i := 0; i < 5000; i++ {
+		b.WriteString(strconv.Itoa((i * 7919) % 1000))
+		b.WriteString("\tline\n")
+	}
+	input := b.String()
+
+	for _, args := range [][]string{{}, {"-n"}, {"-nru"}} {
+		want, _, err := runCLI(t, args, input)
+		if err != nil {
+			t.Fatalf("\n%v: Неожиданная ошибка: %v", args, err)
+		}
+		got, errOut, err := runCLI(t, append([]string{"-S", "1K", "-T", t.TempDir()}, args...), input)
+		if err != nil {
+			t.Fatalf("\n%v -S 1K: Неожиданная ошибка: %v\nstderr: %s", args, err, errOut)
+		}
+		if got != want {
+			t.Errorf("\n%v -S 1K: Вывод внешней сортировки отличается от сортировки в памяти", args)
+		}
+	}
+}
+
+func TestMultipleFilesAndMerge(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.txt": "1\n4\n7",
+		"b.txt": "2\n5\n8\n",
+		"c.txt": "3\n6\n9\n",
+	}
+	var paths []string
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(files[name]), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+
+	// Несколько файлов сортируются вместе
+	out, errOut, err := runCLI(t, []string{paths[2], paths[0], paths[1]}, "")
+	if err != nil {
+		t.Fatalf("\nНеожиданная ошибка: %v\nstderr: %s", err, errOut)
+	}
+	if want := "1\n2\n3\n4\n5\n6\n7\n8\n9"; out != want {
+		t.Errorf("\nОжидалось:\n%q\nПолучилось:\n%q", want, out)
+	}
+
+	// Слияние уже отсортированных файлов (-m)
+	out, errOut, err = runCLI(t, append([]string{"-m"}, paths...), "")
+	if err != nil {
+		t.Fatalf("\n-m: Неожиданная ошибка: %v\nstderr: %s", err, errOut)
+	}
+	if want := "1\n2\n3\n4\n5\n6\n7\n8\n9"; out != want {
+		t.Errorf("\n-m: Ожидалось:\n%q\nПолучилось:\n%q", want, out)
+	}
+}
+
+func TestParallelSort(t *testing.T) {
+	var b strings.Builder
+	for i := 0; i < 20000; i++ {
+		b.WriteString(strconv.Itoa((i * 7919) % 5000))
+		b.WriteString(" line ")
+		b.WriteString(strconv.Itoa(i % 7))
+		b.WriteString("\n")
+	}
+	input := b.String()
+
+	for _, args := range [][]string{{}, {"-k1,1n"}, {"-k3,3nr", "-k1,1"}} {
+		want, _, err := runCLI(t, append([]string{"--parallel=1"}, args...), input)
+		if err != nil {
+			t.Fatalf("\n%v: Неожиданная ошибка: %v", args, err)
+		}
+		got, errOut, err := runCLI(t, append([]string{"--parallel=8"}, args...), input)
+		if err != nil {
+			t.Fatalf("\n%v --parallel=8: Неожиданная ошибка: %v\nstderr: %s", args, err, errOut)
+		}
+		if got != want {
+			t.Errorf("\n%v --parallel=8: Вывод отличается от однопоточной сортировки", args)
+		}
+	}
+}
+
+func TestRandomSort(t *testing.T) {
+	input := "a\nb\nc\na\nd\nb\ne\nc\na"
+
+	first, errOut, err := runCLI(t, []string{"-R", "--seed=fixtures"}, input)
+	if err != nil {
+		t.Fatalf("\n-R: Неожиданная ошибка: %v\nstderr: %s", err, errOut)
+	}
+	second, _, _ := runCLI(t, []string{"-R", "--seed=fixtures"}, input)
+	if first != second {
+		t.Errorf("\n-R: Один и тот же seed дал разный порядок:\n%q\n%q", first, second)
+	}
+
+	// Одинаковые ключи идут подряд
+	seen := map[string]bool{}
+	lines := strings.Split(first, "\n")
+	for i, ln := range lines {
+		if i > 0 && lines[i-1] == ln {
+			continue
+		}
+		if seen[ln] {
+			t.Errorf("\n-R: Одинаковые строки %q не сгруппированы: %q", ln, first)
+		}
+		seen[ln] = true
+	}
+	if len(lines) != 9 || len(seen) != 5 {
+		t.Errorf("\n-R: Потеряны строки: %q", first)
+	}
+}
+
+func TestZeroTerminatedAndOutput(t *testing.T) {
+	// Строки, разделённые NUL (-z), могут содержать перевод строки
+	out, errOut, err := runCLI(t, []string{"-z"}, "b\x00a\nx\x00c\x00")
+	if err != nil {
+		t.Fatalf("\n-z: Неожиданная ошибка: %v\nstderr: %s", err, errOut)
+	}
+	if want := "a\nx\x00b\x00c\x00"; out != want {
+		t.Errorf("\n-z: Ожидалось:\n%q\nПолучилось:\n%q", want, out)
+	}
+
+	// -o может указывать на входной файл
+	path := filepath.Join(t.TempDir(), "data.txt")
+	if err := os.WriteFile(path, []byte("c\nb\na\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out, errOut, err = runCLI(t, []string{"-o", path, path}, "")
+	if err != nil {
+		t.Fatalf("\n-o: Неожиданная ошибка: %v\nstderr: %s", err, errOut)
+	}
+	if out != "" {
+		t.Errorf("\n-o: Ожидался пустой stdout, получили %q", out)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "a\nb\nc\n"; string(data) != want {
+		t.Errorf("\n-o: Ожидалось:\n%q\nПолучилось:\n%q", want, string(data))
+	}
+}
+
+func TestCheckDiagnostics(t *testing.T) {
+	input := "b\na\nc\nb"
+
+	_, errOut, err := runCLI(t, []string{"-c"}, input)
+	if err == nil {
+		t.Errorf("\n-c: Ожидалась ошибка при неотсортированных данных")
+	}
+	if want := "gosort: -:2: disorder: a"; errOut != want {
+		t.Errorf("\n-c: Ожидалось в stderr:\n%q\nПолучилось:\n%q", want, errOut)
+	}
+
+	_, errOut, err = runCLI(t, []string{"-C"}, input)
+	if err == nil {
+		t.Errorf("\n-C: Ожидалась ошибка при неотсортированных данных")
+	}
+	if errOut != "" {
+		t.Errorf("\n-C: Ожидался пустой stderr, получили %q", errOut)
+	}
+
+	_, errOut, err = runCLI(t, []string{"--check=all"}, input)
+	if err == nil {
+		t.Errorf("\n--check=all: Ожидалась ошибка при неотсортированных данных")
+	}
+	if want := "gosort: -:2: disorder: a\ngosort: -:4: disorder: b"; errOut != want {
+		t.Errorf("\n--check=all: Ожидалось в stderr:\n%q\nПолучилось:\n%q", want, errOut)
+	}
+}
+
+func TestCompressed(t *testing.T) {
+	dir := t.TempDir()
+
+	// gzip на входе распознаётся по сигнатуре
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	_, _ = zw.Write([]byte("c\nb\n"))
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	gzPath := filepath.Join(dir, "in.gz")
+	if err := os.WriteFile(gzPath, gz.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	plainPath := filepath.Join(dir, "in.txt")
+	if err := os.WriteFile(plainPath, []byte("a\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// текст, начинающийся с "BZh", не считается bzip2
+	bzhPath := filepath.Join(dir, "bzh.txt")
+	if err := os.WriteFile(bzhPath, []byte("BZhang Wei\nAlice\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{{bzhPath}, {}} {
+		out, errOut, err := runCLI(t, args, "BZhang Wei\nAlice\n")
+		if err != nil {
+			t.Fatalf("\nТекст с \"BZh\" (%v): Неожиданная ошибка: %v\nstderr: %s", args, err, errOut)
+		}
+		if want := "Alice\nBZhang Wei"; out != want {
+			t.Errorf("\nТекст с \"BZh\" (%v): Ожидалось %q, получилось %q", args, want, out)
+		}
+	}
+
+	// сжатый zstd вывод читается обратно
+	zstPath := filepath.Join(dir, "out.zst")
+	if _, errOut, err := runCLI(t, []string{"--compress-output=zstd", "-o", zstPath, gzPath, plainPath}, ""); err != nil {
+		t.Fatalf("\n--compress-output: Неожиданная ошибка: %v\nstderr: %s", err, errOut)
+	}
+	data, err := os.ReadFile(zstPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(data, []byte{0x28, 0xb5, 0x2f, 0xfd}) {
+		t.Errorf("\n--compress-output=zstd: Ожидалась сигнатура zstd, получили % x", data[:min(4, len(data))])
+	}
+	out, errOut, err := runCLI(t, []string{zstPath}, "")
+	if err != nil {
+		t.Fatalf("\nЧтение zstd: Неожиданная ошибка: %v\nstderr: %s", err, errOut)
+	}
+	if want := "a\nb\nc"; out != want {
+		t.Errorf("\nЧтение zstd: Ожидалось %q, получилось %q", want, out)
+	}
+
+	// сжатые временные файлы дают тот же результат
+	var in strings.Builder
+	for i := 3000; i > 0; i-- {
+		in.WriteString(strconv.Itoa(i) + "\n")
+	}
+	plain, _, err := runCLI(t, []string{"-n", "-S", "4K"}, in.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	packed, errOut, err := runCLI(t, []string{"-n", "-S", "4K", "--compress-temp=gzip"}, in.String())
+	if err != nil {
+		t.Fatalf("\n--compress-temp: Неожиданная ошибка: %v\nstderr: %s", err, errOut)
+	}
+	if packed != plain {
+		t.Errorf("\n--compress-temp: результат отличается от сортировки без сжатия")
+	}
+}
+
+func TestLibrary(t *testing.T) {
+	key, err := gosort.Field(2).To(2).Numeric().Reverse().Spec()
+	if err != nil {
+		t.Fatalf("\nField(2).To(2).Numeric().Reverse(): неожиданная ошибка: %v", err)
+	}
+	opts := gosort.Options{Keys: []gosort.KeySpec{key}}
+
+	var out bytes.Buffer
+	if err := gosort.Sort(strings.NewReader("a 1\nb 10\nc 2\n"), &out, opts); err != nil {
+		t.Fatalf("\nSort: неожиданная ошибка: %v", err)
+	}
+	if want := "b 10\nc 2\na 1\n"; out.String() != want {
+		t.Errorf("\nSort: ожидалось %q, получилось %q", want, out.String())
+	}
+
+	err = gosort.Check(strings.NewReader("b 10\na 1\nc 2\n"), opts)
+	var disorder *gosort.DisorderError
+	if !errors.As(err, &disorder) || !errors.Is(err, gosort.ErrDisorder) {
+		t.Fatalf("\nCheck: ожидалась DisorderError, получили %v", err)
+	}
+	if disorder.Line != 3 || disorder.Text != "c 2" {
+		t.Errorf("\nCheck: ожидался беспорядок в строке 3 (\"c 2\"), получили %d (%q)", disorder.Line, disorder.Text)
+	}
+
+	lines := []string{"v1.10", "v1.9", "v1.2"}
+	less, err := gosort.Less(lines, gosort.Options{Version: true})
+	if err != nil {
+		t.Fatalf("\nLess: неожиданная ошибка: %v", err)
+	}
+	sort.Slice(lines, less)
+	if got := strings.Join(lines, " "); got != "v1.2 v1.9 v1.10" {
+		t.Errorf("\nLess: ожидалось %q, получилось %q", "v1.2 v1.9 v1.10", got)
+	}
+
+	if _, err := gosort.Field(1).Numeric().Month().Spec(); err == nil {
+		t.Errorf("\nField(1).Numeric().Month(): ожидалась ошибка несовместимых режимов")
+	}
+	if _, err := gosort.NewComparator(gosort.Options{Numeric: true, Month: true}); err == nil {
+		t.Errorf("\nNewComparator(-n -M): ожидалась ошибка несовместимых режимов")
+	}
+}
@@ -1,24 +1,48 @@
 package cmd
 
 import (
+	"fmt"
+	"grep/internal/color"
 	"grep/internal/config"
 	"grep/internal/run"
 	"grep/internal/stream"
+	"io"
 	"os"
+	"slices"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
-var opt config.Flags
+var (
+	opt config.Flags
+	cli cliFlags
+)
+
+// cliFlags are the flags that resolve merges into config.Flags.
+type cliFlags struct {
+	context   int    // -C: the default for -A and -B
+	colorWhen string // --color: auto, always or never; becomes Flags.Colors
+}
 
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
-	Use:   "grep [FILENAME (optional)]",
+	Use:   "grep PATTERN [FILE...]",
 	Short: "Grep -- a utility to search for regular expressions.",
-	Long:  `Search text for regular expressions / plain text strings. Don't input a file name if you want to read STDIN.`,
+	Long:  `Search text for regular expressions / plain text strings. Don't input a file name if you want to read STDIN; "-" also means STDIN. With -e or -f every argument is a file.`,
+	// option errors print a one-line message, not the whole flag list
+	SilenceUsage: true,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		defaults, err := config.Defaults()
+		if err != nil {
+			return err
+		}
+		return resolve(cmd.Flags(), &opt, &cli, defaults)
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if len(args) == 0 || len(args) > 2 {
-			return cmd.Usage()
+		if len(args) == 0 && len(opt.Patterns) == 0 && len(opt.PatternFiles) == 0 {
+			_ = cmd.Usage()
+			os.Exit(2)
 		}
 		return run.Run(args, opt, stream.NewProcessor(&opt))
 	},
@@ -29,23 +53,149 @@ var rootCmd = &cobra.Command{
 func Execute() {
 	err := rootCmd.Execute()
 	if err != nil {
-		os.Exit(1)
+		// usage errors, as in GNU grep; run.Run exits by itself
+		os.Exit(2)
 	}
 }
 
 func init() {
-	var c int
+	defineFlags(rootCmd.Flags(), &opt, &cli)
+}
+
+// defineFlags defines the flags of grep in fs, bound to opt and cli.
+func defineFlags(fs *pflag.FlagSet, opt *config.Flags, cli *cliFlags) {
+	// Define a dummy --help flag (no shorthand) to prevent Cobra from reserving -h.
+	fs.Bool("help", false, "help for grep")
+
+	fs.IntVarP(&opt.After, "after-context", "A", 0, "show N lines after each found expression")
+	fs.IntVarP(&opt.Before, "before-context", "B", 0, "show N lines before each found expression")
+
+	fs.IntVarP(&cli.context, "context", "C", 0, "show N lines before and after each found expression; -A and -B win over it")
 
-	rootCmd.Flags().IntVarP(&opt.After, "after-context", "A", 0, "show N lines after each found expression")
-	rootCmd.Flags().IntVarP(&opt.Before, "before-context", "B", 0, "show N lines before each found expression")
+	fs.BoolVarP(&opt.OnlyCount, "count", "c", false, "show only matching count")
+	fs.BoolVarP(&opt.IgnoreCase, "ignore-case", "i", false, "ignore case matching")
+	fs.BoolVarP(&opt.Invert, "invert", "v", false, "invert matching")
+	fs.BoolVarP(&opt.FixedString, "fixed-string", "F", false, "fix string instead of regexp")
+	fs.BoolVarP(&opt.PrintNumbers, "print-numbers", "n", false, "print line numbers")
+	fs.IntVarP(&opt.MaxCount, "max-count", "m", 0, "stop after NUM selected lines, still printing their trailing context")
+	fs.StringVar(&opt.GroupSeparator, "group-separator", config.DefaultGroupSeparator, "print SEP between groups of context lines")
+	fs.BoolVar(&opt.NoGroupSeparator, "no-group-separator", false, "print nothing between groups of context lines")
 
-	rootCmd.Flags().IntVarP(&c, "context", "C", 0, "show N lines before and after each found expression")
-	opt.After = max(opt.After, c)
-	opt.Before = max(opt.Before, c)
+	fs.StringArrayVarP(&opt.Patterns, "regexp", "e", nil, "use PATTERN for matching; repeatable, a line matches if any pattern does")
+	fs.StringArrayVarP(&opt.PatternFiles, "file", "f", nil, "take patterns from FILE, one per line; repeatable")
+	fs.BoolVarP(&opt.WordRegexp, "word-regexp", "w", false, "match only whole words")
+	fs.BoolVarP(&opt.LineRegexp, "line-regexp", "x", false, "match only whole lines")
+	fs.BoolVarP(&opt.OnlyMatching, "only-matching", "o", false, "print only the matched parts of lines, each on its own line")
+
+	fs.BoolVarP(&opt.Recursive, "recursive", "r", false, "search directories recursively (the current one if no FILE is given)")
+	fs.StringArrayVar(&opt.Include, "include", nil, "search only files whose base name matches GLOB; repeatable")
+	fs.StringArrayVar(&opt.Exclude, "exclude", nil, "skip files whose base name matches GLOB; repeatable")
+	fs.StringArrayVar(&opt.ExcludeDir, "exclude-dir", nil, "skip directories whose base name matches GLOB; repeatable")
+	fs.BoolVar(&opt.GitIgnore, "gitignore", false, "skip .git and files ignored by .gitignore files while searching recursively")
+	fs.BoolVarP(&opt.WithFilename, "with-filename", "H", false, "print the file name for each match")
+	fs.BoolVarP(&opt.NoFilename, "no-filename", "h", false, "never print file names")
+
+	fs.StringVar(&cli.colorWhen, "color", "never", "highlight matches, line numbers, separators and file names: auto, always or never; colors are taken from GREP_COLORS")
+	fs.Lookup("color").NoOptDefVal = "auto"
+}
+
+// resolve completes opt after the command line is parsed into fs: flags not
+// given on the command line take their values from defaults, -C is merged
+// into -A and -B, --color is resolved and conflicts are reported.
+func resolve(fs *pflag.FlagSet, opt *config.Flags, cli *cliFlags, defaults []string) error {
+	defaulted, err := applyDefaults(fs, defaults)
+	if err != nil {
+		return fmt.Errorf("default options: %w", err)
+	}
+
+	if cli.context < 0 {
+		return fmt.Errorf("invalid context length: %d", cli.context)
+	}
+	opt.After = mergeContext(fs, defaulted, "after-context", opt.After, cli.context)
+	opt.Before = mergeContext(fs, defaulted, "before-context", opt.Before, cli.context)
+	for _, name := range []string{"after-context", "before-context", "context"} {
+		opt.Context = opt.Context || fs.Changed(name) || defaulted[name]
+	}
+	opt.HasMaxCount = fs.Changed("max-count") || defaulted["max-count"]
 
-	rootCmd.Flags().BoolVarP(&opt.OnlyCount, "count", "c", false, "show only matching count")
-	rootCmd.Flags().BoolVarP(&opt.IgnoreCase, "ignore-case", "i", false, "ignore case matching")
-	rootCmd.Flags().BoolVarP(&opt.Invert, "invert", "v", false, "invert matching")
-	rootCmd.Flags().BoolVarP(&opt.FixedString, "fixed-string", "F", false, "fix string instead of regexp")
-	rootCmd.Flags().BoolVarP(&opt.PrintNumbers, "print-numbers", "n", false, "print line numbers")
+	switch cli.colorWhen {
+	case "auto", "always", "never":
+	default:
+		return fmt.Errorf("invalid argument %q for --color: want auto, always or never", cli.colorWhen)
+	}
+	opt.Colors = color.Resolve(cli.colorWhen, os.Stdout)
+
+	return opt.Validate()
+}
+
+// mergeContext returns the value of -A or -B named name, given the one of -C.
+// The command line wins over defaults; given in the same place, -A and -B win
+// over -C whatever their order, as in GNU grep.
+func mergeContext(fs *pflag.FlagSet, defaulted map[string]bool, name string, value, context int) int {
+	switch {
+	case fs.Changed(name):
+		return value
+	case fs.Changed("context"):
+		return context
+	case defaulted[name]:
+		return value
+	default:
+		return context
+	}
+}
+
+// opposites are the flags that conflict with a flag. A default is dropped
+// when the command line gives one of its opposites.
+var opposites = map[string][]string{
+	"count":         {"only-matching"},
+	"only-matching": {"count", "invert"},
+	"invert":        {"only-matching"},
+	"with-filename": {"no-filename"},
+	"no-filename":   {"with-filename"},
+}
+
+// applyDefaults parses args, which must hold only flags, and sets each of
+// them in fs unless the command line has already set it or an opposite.
+// The flags set stay unchanged in fs and are returned instead.
+func applyDefaults(fs *pflag.FlagSet, args []string) (map[string]bool, error) {
+	if len(args) == 0 {
+		return nil, nil
+	}
+	var (
+		opt config.Flags
+		cli cliFlags
+	)
+	ds := pflag.NewFlagSet("defaults", pflag.ContinueOnError)
+	ds.SetOutput(io.Discard)
+	defineFlags(ds, &opt, &cli)
+	if err := ds.Parse(args); err != nil {
+		return nil, err
+	}
+	if ds.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected argument %q", ds.Arg(0))
+	}
+
+	defaulted := make(map[string]bool)
+	var err error
+	ds.Visit(func(d *pflag.Flag) {
+		if err != nil {
+			return
+		}
+		// patterns there would make the pattern operand of every call a file
+		if d.Name == "regexp" || d.Name == "file" {
+			err = fmt.Errorf("--%s cannot be a default option", d.Name)
+			return
+		}
+		f := fs.Lookup(d.Name)
+		if f == nil || f.Changed || slices.ContainsFunc(opposites[d.Name], fs.Changed) {
+			return
+		}
+		if sv, ok := d.Value.(pflag.SliceValue); ok {
+			err = f.Value.(pflag.SliceValue).Replace(sv.GetSlice())
+		} else {
+			err = f.Value.Set(d.Value.String())
+		}
+		defaulted[d.Name] = true
+	})
+	return defaulted, err
 }
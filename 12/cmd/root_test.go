@@ -0,0 +1,250 @@
+package cmd
+
+import (
+	"grep/internal/color"
+	"grep/internal/config"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		defaults []string
+		want     func(f *config.Flags) // changes to the flags of a plain call
+		err      string
+	}{
+		{
+			name: "no flags",
+			want: func(f *config.Flags) {},
+		},
+		{
+			name: "context sets after and before",
+			args: []string{"-C", "2"},
+			want: func(f *config.Flags) { f.Context, f.After, f.Before = true, 2, 2 },
+		},
+		{
+			name: "after wins over context",
+			args: []string{"-A", "1", "-C", "3"},
+			want: func(f *config.Flags) { f.Context, f.After, f.Before = true, 1, 3 },
+		},
+		{
+			name: "before wins over context in any order",
+			args: []string{"-C", "3", "-B", "0"},
+			want: func(f *config.Flags) { f.Context, f.After, f.Before = true, 3, 0 },
+		},
+		{
+			name: "after and before without context",
+			args: []string{"-A", "1", "-B", "2"},
+			want: func(f *config.Flags) { f.Context, f.After, f.Before = true, 1, 2 },
+		},
+		{
+			name: "zero after still asks for context",
+			args: []string{"-A", "0"},
+			want: func(f *config.Flags) { f.Context = true },
+		},
+		{
+			name: "zero context still asks for context",
+			args: []string{"-C", "0"},
+			want: func(f *config.Flags) { f.Context = true },
+		},
+		{
+			name: "negative context",
+			args: []string{"-C", "-1"},
+			err:  "invalid context length: -1",
+		},
+		{
+			name: "negative after",
+			args: []string{"-A", "-2"},
+			err:  "invalid context length: -2",
+		},
+		{
+			name: "zero max count is a limit",
+			args: []string{"-m", "0"},
+			want: func(f *config.Flags) { f.HasMaxCount = true },
+		},
+		{
+			name: "negative max count",
+			args: []string{"-m", "-1"},
+			err:  "invalid max count: -1",
+		},
+		{
+			name: "count with only matching",
+			args: []string{"-c", "-o"},
+			err:  "-c and -o cannot be used together",
+		},
+		{
+			name: "only matching with invert",
+			args: []string{"-o", "-v"},
+			err:  "-o and -v cannot be used together",
+		},
+		{
+			name: "with and without file names",
+			args: []string{"-H", "-h"},
+			err:  "-H and -h cannot be used together",
+		},
+		{
+			name: "count with invert and context",
+			args: []string{"-c", "-v", "-C", "1"},
+			want: func(f *config.Flags) { f.OnlyCount, f.Invert, f.Context, f.After, f.Before = true, true, true, 1, 1 },
+		},
+		{
+			name: "only matching with word and line regexps",
+			args: []string{"-o", "-w", "-x", "-i"},
+			want: func(f *config.Flags) {
+				f.OnlyMatching, f.WordRegexp, f.LineRegexp, f.IgnoreCase = true, true, true, true
+			},
+		},
+		{
+			name: "group separators",
+			args: []string{"--group-separator", "==", "--no-group-separator"},
+			want: func(f *config.Flags) { f.GroupSeparator, f.NoGroupSeparator = "==", true },
+		},
+		{
+			name: "color always",
+			args: []string{"--color=always"},
+			want: func(f *config.Flags) { f.Colors = color.Resolve("always", nil) },
+		},
+		{
+			name: "color never",
+			args: []string{"--color=never"},
+			want: func(f *config.Flags) {},
+		},
+		{
+			name: "invalid color",
+			args: []string{"--color=sometimes"},
+			err:  `invalid argument "sometimes" for --color`,
+		},
+		{
+			name:     "defaults are applied",
+			defaults: []string{"-n", "-i", "--exclude-dir", ".git", "-C", "1"},
+			want: func(f *config.Flags) {
+				f.PrintNumbers, f.IgnoreCase, f.ExcludeDir, f.Context, f.After, f.Before = true, true, []string{".git"}, true, 1, 1
+			},
+		},
+		{
+			name:     "command line wins over defaults",
+			args:     []string{"-m", "5", "--exclude", "*.log", "-C", "0"},
+			defaults: []string{"-m", "1", "--exclude", "*.gz", "--exclude", "*.bz2", "-C", "2"},
+			want: func(f *config.Flags) {
+				f.MaxCount, f.HasMaxCount, f.Exclude, f.Context = 5, true, []string{"*.log"}, true
+			},
+		},
+		{
+			name:     "context from defaults and after from the command line",
+			args:     []string{"-A", "0"},
+			defaults: []string{"-C", "2"},
+			want:     func(f *config.Flags) { f.Context, f.After, f.Before = true, 0, 2 },
+		},
+		{
+			name:     "context from the command line wins over after from defaults",
+			args:     []string{"-C", "3"},
+			defaults: []string{"-A", "1"},
+			want:     func(f *config.Flags) { f.Context, f.After, f.Before = true, 3, 3 },
+		},
+		{
+			name:     "after from defaults wins over context from defaults",
+			defaults: []string{"-A", "1", "-C", "2"},
+			want:     func(f *config.Flags) { f.Context, f.After, f.Before = true, 1, 2 },
+		},
+		{
+			name:     "command line only matching drops count from defaults",
+			args:     []string{"-o"},
+			defaults: []string{"-c"},
+			want:     func(f *config.Flags) { f.OnlyMatching = true },
+		},
+		{
+			name:     "command line count drops only matching from defaults",
+			args:     []string{"-c"},
+			defaults: []string{"-o", "-n"},
+			want:     func(f *config.Flags) { f.OnlyCount, f.PrintNumbers = true, true },
+		},
+		{
+			name:     "command line invert drops only matching from defaults",
+			args:     []string{"-v"},
+			defaults: []string{"-o"},
+			want:     func(f *config.Flags) { f.Invert = true },
+		},
+		{
+			name:     "command line only matching drops invert from defaults",
+			args:     []string{"-o"},
+			defaults: []string{"-v"},
+			want:     func(f *config.Flags) { f.OnlyMatching = true },
+		},
+		{
+			name:     "command line no filename drops with filename from defaults",
+			args:     []string{"-h"},
+			defaults: []string{"-H"},
+			want:     func(f *config.Flags) { f.NoFilename = true },
+		},
+		{
+			name:     "command line with filename drops no filename from defaults",
+			args:     []string{"-H"},
+			defaults: []string{"-h"},
+			want:     func(f *config.Flags) { f.WithFilename = true },
+		},
+		{
+			name:     "defaults conflicting with each other",
+			defaults: []string{"-c", "-o"},
+			err:      "-c and -o cannot be used together",
+		},
+		{
+			name:     "patterns are not defaults even if given on the command line",
+			args:     []string{"-e", "y"},
+			defaults: []string{"-e", "x"},
+			err:      "--regexp cannot be a default option",
+		},
+		{
+			name:     "patterns are not defaults",
+			defaults: []string{"-e", "x"},
+			err:      "--regexp cannot be a default option",
+		},
+		{
+			name:     "operands are not defaults",
+			defaults: []string{"-n", "file.txt"},
+			err:      `unexpected argument "file.txt"`,
+		},
+		{
+			name:     "unknown default flag",
+			defaults: []string{"--frobnicate"},
+			err:      "unknown flag: --frobnicate",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				opt config.Flags
+				cli cliFlags
+			)
+			fs := pflag.NewFlagSet("grep", pflag.ContinueOnError)
+			fs.SetOutput(io.Discard)
+			defineFlags(fs, &opt, &cli)
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatalf("Parse(%q) returned error: %v", tt.args, err)
+			}
+
+			err := resolve(fs, &opt, &cli, tt.defaults)
+
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("resolve() error = %v, expected %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolve() returned error: %v", err)
+			}
+			want := config.Flags{GroupSeparator: config.DefaultGroupSeparator}
+			tt.want(&want)
+			if !reflect.DeepEqual(opt, want) {
+				t.Errorf("resolve() flags = %+v, expected %+v", opt, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package color
+
+import (
+	"os"
+	"strings"
+)
+
+// Palette holds SGR sequences for the parts of the output, named as in GREP_COLORS.
+type Palette struct {
+	MatchSelected string // ms: matched text in selected lines
+	MatchContext  string // mc: matched text in context lines
+	Selected      string // sl: whole selected lines
+	Context       string // cx: whole context lines
+	FileName      string // fn: file names
+	LineNumber    string // ln: line numbers
+	ByteOffset    string // bn: byte offsets
+	Separator     string // se: separators between fields and groups
+	Reverse       bool   // rv: swap sl and cx with -v
+	NoErase       bool   // ne: do not append "erase in line" to sequences
+}
+
+// Default is the palette of GNU grep.
+var Default = Palette{
+	MatchSelected: "01;31",
+	MatchContext:  "01;31",
+	FileName:      "35",
+	LineNumber:    "32",
+	ByteOffset:    "32",
+	Separator:     "36",
+}
+
+// Parse applies a GREP_COLORS value such as "ms=01;32:fn=34:ne" to Default.
+// Unknown capabilities are ignored, as GNU grep does.
+func Parse(env string) Palette {
+	p := Default
+	for _, item := range strings.Split(env, ":") {
+		name, value, _ := strings.Cut(item, "=")
+		switch name {
+		case "mt":
+			p.MatchSelected, p.MatchContext = value, value
+		case "ms":
+			p.MatchSelected = value
+		case "mc":
+			p.MatchContext = value
+		case "sl":
+			p.Selected = value
+		case "cx":
+			p.Context = value
+		case "fn":
+			p.FileName = value
+		case "ln":
+			p.LineNumber = value
+		case "bn":
+			p.ByteOffset = value
+		case "se":
+			p.Separator = value
+		case "rv":
+			p.Reverse = true
+		case "ne":
+			p.NoErase = true
+		}
+	}
+	return p
+}
+
+// Paint wraps text in the SGR sequence; an empty sequence leaves text as is.
+func (p *Palette) Paint(sgr, text string) string {
+	if p == nil || sgr == "" || text == "" {
+		return text
+	}
+	el := "\033[K"
+	if p.NoErase {
+		el = ""
+	}
+	return "\033[" + sgr + "m" + el + text + "\033[m" + el
+}
+
+// Resolve turns --color=never|always|auto into a palette, or nil for no color.
+// auto colors only a terminal that is not "dumb".
+func Resolve(when string, out *os.File) *Palette {
+	switch when {
+	case "always":
+	case "auto":
+		if os.Getenv("TERM") == "dumb" || !isTerminal(out) {
+			return nil
+		}
+	default:
+		return nil
+	}
+	p := Parse(os.Getenv("GREP_COLORS"))
+	return &p
+}
+
+func isTerminal(f *os.File) bool {
+	st, err := f.Stat()
+	return err == nil && st.Mode()&os.ModeCharDevice != 0
+}
@@ -1,12 +1,72 @@
 package config
 
+import (
+	"errors"
+	"fmt"
+	"grep/internal/color"
+)
+
+// DefaultGroupSeparator is printed between groups of context lines, as in GNU grep.
+const DefaultGroupSeparator = "--"
+
 // Flags represent a config structure for program flags
 type Flags struct {
 	After        int
 	Before       int
+	Context      bool // -A, -B or -C was given, even as 0: groups of output are separated
 	OnlyCount    bool
 	IgnoreCase   bool
 	Invert       bool
 	FixedString  bool
 	PrintNumbers bool
+
+	MaxCount         int    // -m: stop after NUM selected lines
+	HasMaxCount      bool   // -m was given, even as 0; otherwise there is no limit
+	GroupSeparator   string // --group-separator: the line between groups of context lines
+	NoGroupSeparator bool   // --no-group-separator: print nothing between groups
+
+	Patterns     []string // -e: patterns; the first operand is the pattern unless -e or -f is given
+	PatternFiles []string // -f: files with one pattern per line
+	WordRegexp   bool     // -w: match whole words only
+	LineRegexp   bool     // -x: match whole lines only
+	OnlyMatching bool     // -o: print only the matched parts, one per line
+
+	Colors *color.Palette // --color: how to highlight the output; nil means plain text
+
+	Recursive    bool     // -r: search directories
+	Include      []string // --include: search only files whose base name matches a glob
+	Exclude      []string // --exclude: skip files whose base name matches a glob
+	ExcludeDir   []string // --exclude-dir: skip directories whose base name matches a glob
+	GitIgnore    bool     // --gitignore: skip files ignored by .gitignore files while walking
+	WithFilename bool     // -H: prefix lines with the file name
+	NoFilename   bool     // -h: never prefix lines with the file name
+}
+
+// Separator returns the line printed between non-adjacent groups of output
+// and whether there is one. Groups only exist when context was asked for,
+// however many lines of it.
+func (f *Flags) Separator() (string, bool) {
+	if f.NoGroupSeparator || f.OnlyCount || !f.Context {
+		return "", false
+	}
+	return f.GroupSeparator, true
+}
+
+// Validate reports invalid values and options that cannot be used together.
+func (f *Flags) Validate() error {
+	switch {
+	case f.After < 0:
+		return fmt.Errorf("invalid context length: %d", f.After)
+	case f.Before < 0:
+		return fmt.Errorf("invalid context length: %d", f.Before)
+	case f.MaxCount < 0:
+		return fmt.Errorf("invalid max count: %d", f.MaxCount)
+	case f.OnlyCount && f.OnlyMatching:
+		return errors.New("-c and -o cannot be used together")
+	case f.OnlyMatching && f.Invert:
+		return errors.New("-o and -v cannot be used together: inverted lines have no matches to print")
+	case f.WithFilename && f.NoFilename:
+		return errors.New("-H and -h cannot be used together")
+	}
+	return nil
 }
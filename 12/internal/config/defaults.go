@@ -0,0 +1,94 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// OptionsEnv names the environment variable with default options, like
+// GREP_OPTIONS of older GNU grep. It is named differently so that GNU grep,
+// which now rejects GREP_OPTIONS, is not affected.
+const OptionsEnv = "GOGREP_OPTIONS"
+
+// OptionsFile is the file with default options, relative to the user config
+// directory ($XDG_CONFIG_HOME or ~/.config on Linux).
+var OptionsFile = filepath.Join("grep", "options")
+
+// Defaults returns the default options: those of OptionsFile, then those of
+// OptionsEnv, so the environment wins over the file. A missing file is fine.
+func Defaults() ([]string, error) {
+	var args []string
+	if dir, err := os.UserConfigDir(); err == nil {
+		name := filepath.Join(dir, OptionsFile)
+		data, err := os.ReadFile(name)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+		case err != nil:
+			return nil, err
+		default:
+			for i, line := range strings.Split(string(data), "\n") {
+				words, err := SplitOptions(line)
+				if err != nil {
+					return nil, fmt.Errorf("%s:%d: %w", name, i+1, err)
+				}
+				args = append(args, words...)
+			}
+		}
+	}
+	words, err := SplitOptions(os.Getenv(OptionsEnv))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", OptionsEnv, err)
+	}
+	return append(args, words...), nil
+}
+
+// SplitOptions splits a line of options into words as a shell does: words
+// are separated by blanks, quotes and backslashes escape them, and a word
+// starting with '#' starts a comment.
+func SplitOptions(s string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+	for _, r := range s {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else if r == '\\' && quote == '"' {
+				escaped = true
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\\':
+			escaped, inWord = true, true
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		case r == '#' && !inWord:
+			return words, nil
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 || escaped {
+		return nil, fmt.Errorf("unterminated quote or escape in %q", s)
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
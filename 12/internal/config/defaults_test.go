@@ -0,0 +1,71 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSplitOptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+		err      bool
+	}{
+		{name: "empty", input: "", expected: nil},
+		{name: "blanks", input: " -n\t-i  ", expected: []string{"-n", "-i"}},
+		{name: "single quotes", input: `--group-separator '~ ~' -n`, expected: []string{"--group-separator", "~ ~", "-n"}},
+		{name: "double quotes with escape", input: `--exclude "a \"b\""`, expected: []string{"--exclude", `a "b"`}},
+		{name: "backslash", input: `--exclude a\ b`, expected: []string{"--exclude", "a b"}},
+		{name: "empty word", input: `--group-separator ''`, expected: []string{"--group-separator", ""}},
+		{name: "comment", input: "-n # line numbers", expected: []string{"-n"}},
+		{name: "hash inside a word", input: "--exclude a#b", expected: []string{"--exclude", "a#b"}},
+		{name: "unterminated quote", input: `-n "x`, err: true},
+		{name: "trailing backslash", input: `-n \`, err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			words, err := SplitOptions(tt.input)
+
+			if (err != nil) != tt.err {
+				t.Fatalf("SplitOptions(%q) error = %v, expected error: %v", tt.input, err, tt.err)
+			}
+			if !tt.err && !reflect.DeepEqual(words, tt.expected) {
+				t.Errorf("SplitOptions(%q) = %q, expected %q", tt.input, words, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDefaults(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("HOME", dir)
+
+	t.Setenv(OptionsEnv, "")
+	if args, err := Defaults(); err != nil || len(args) != 0 {
+		t.Errorf("Defaults() without a file = %q, %v, expected nothing", args, err)
+	}
+
+	name := filepath.Join(dir, OptionsFile)
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(name, []byte("# defaults\n-n\n--exclude-dir .git\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(OptionsEnv, "-i -n")
+
+	args, err := Defaults()
+
+	if err != nil {
+		t.Fatalf("Defaults() returned error: %v", err)
+	}
+	// the environment comes last so that it wins
+	if expected := []string{"-n", "--exclude-dir", ".git", "-i", "-n"}; !reflect.DeepEqual(args, expected) {
+		t.Errorf("Defaults() = %q, expected %q", args, expected)
+	}
+}
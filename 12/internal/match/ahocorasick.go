@@ -0,0 +1,265 @@
+package match
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// acMatcher finds fixed strings with an Aho-Corasick automaton, so a line is
+// scanned once however many patterns there are. Matches are leftmost-longest,
+// as in GNU grep -F.
+//
+// With fold, the patterns and the line are compared rune by rune after simple
+// case folding, like (?i) does; the line is folded on the fly, without
+// allocating. Otherwise they are compared byte by byte. Either way a "unit" is
+// what is compared: a byte, or a rune with fold.
+type acMatcher struct {
+	fold bool // -i
+	word bool // -w
+	line bool // -x
+
+	classes [256]uint16 // byte -> column in delta; 0 is for bytes in no pattern
+	width   int         // number of columns
+	delta   []int32     // node*width+class -> next node, failures already followed
+	depth   []int32     // length of the path to the node in bytes
+	units   []int32     // length of the path to the node in units
+	end     []bool      // whether the path to the node is a pattern
+	longest []int32     // units of the longest pattern that is a suffix of the path; 0 if none
+	dict    []int32     // the next node on the failure chain that ends a pattern; -1 if none
+}
+
+// newAC builds the automaton for non-empty patterns.
+func newAC(patterns []string, fold, word, line bool) *acMatcher {
+	m := &acMatcher{fold: fold, word: word, line: line}
+
+	keys := make([]string, len(patterns))
+	for i, p := range patterns {
+		if fold {
+			p = foldString(p)
+		}
+		keys[i] = p
+		for j := 0; j < len(p); j++ {
+			if m.classes[p[j]] == 0 {
+				m.width++
+				m.classes[p[j]] = uint16(m.width)
+			}
+		}
+	}
+	m.width++ // for class 0
+
+	// the trie, with -1 for missing children
+	m.addNode(0, 0)
+	for _, key := range keys {
+		node := int32(0)
+		for j := 0; j < len(key); j++ {
+			i := int(node)*m.width + int(m.classes[key[j]])
+			if m.delta[i] < 0 {
+				units := m.units[node]
+				if !fold || utf8.RuneStart(key[j]) {
+					units++
+				}
+				m.delta[i] = m.addNode(m.depth[node]+1, units)
+			}
+			node = m.delta[i]
+		}
+		m.end[node] = true
+	}
+
+	// failure links in breadth-first order, folded into delta
+	fail := make([]int32, len(m.end))
+	queue := make([]int32, 0, len(m.end))
+	for c := range m.width {
+		if child := m.delta[c]; child < 0 {
+			m.delta[c] = 0
+		} else {
+			queue = append(queue, child)
+		}
+	}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		f := fail[node]
+		if m.end[f] {
+			m.dict[node] = f
+		} else {
+			m.dict[node] = m.dict[f]
+		}
+		if m.end[node] {
+			m.longest[node] = m.units[node]
+		} else {
+			m.longest[node] = m.longest[f]
+		}
+		row, frow := int(node)*m.width, int(f)*m.width
+		for c := range m.width {
+			if child := m.delta[row+c]; child < 0 {
+				m.delta[row+c] = m.delta[frow+c]
+			} else {
+				fail[child] = m.delta[frow+c]
+				queue = append(queue, child)
+			}
+		}
+	}
+	return m
+}
+
+func (m *acMatcher) addNode(depth, units int32) int32 {
+	for range m.width {
+		m.delta = append(m.delta, -1)
+	}
+	m.depth = append(m.depth, depth)
+	m.units = append(m.units, units)
+	m.end = append(m.end, false)
+	m.longest = append(m.longest, 0)
+	m.dict = append(m.dict, -1)
+	return int32(len(m.end) - 1)
+}
+
+func (m *acMatcher) Find(line string, all bool) [][2]int {
+	if m.line {
+		if m.whole(line) {
+			return [][2]int{{0, len(line)}}
+		}
+		return nil
+	}
+	var spans [][2]int
+	for pos := 0; pos < len(line); {
+		start, end, ok := m.next(line, pos)
+		if !ok {
+			break
+		}
+		spans = append(spans, [2]int{start, end})
+		if !all {
+			break
+		}
+		pos = end
+	}
+	return spans
+}
+
+// next finds the leftmost-longest match in line[from:]. With -w it is the
+// leftmost-longest match that is a whole word.
+func (m *acMatcher) next(line string, from int) (start, end int, ok bool) {
+	node := int32(0)
+	units := 0     // units read since from
+	bestUnit := -1 // the start of the best match so far, in units
+	var buf [utf8.UTFMax]byte
+	for pos := from; pos < len(line); {
+		if b := line[pos]; !m.fold || b < utf8.RuneSelf {
+			if m.fold && 'a' <= b && b <= 'z' {
+				b -= 'a' - 'A'
+			}
+			node = m.delta[int(node)*m.width+int(m.classes[b])]
+			pos++
+		} else {
+			r, size := utf8.DecodeRuneInString(line[pos:])
+			n := utf8.EncodeRune(buf[:], foldRune(r))
+			for _, b := range buf[:n] {
+				node = m.delta[int(node)*m.width+int(m.classes[b])]
+			}
+			pos += size
+		}
+		units++
+
+		if m.longest[node] > 0 {
+			if !m.word {
+				if s := units - int(m.longest[node]); bestUnit < 0 || s <= bestUnit {
+					bestUnit, start, end = s, m.back(line, pos, int(m.longest[node])), pos
+				}
+			} else {
+				// patterns ending here, longest first
+				n := node
+				if !m.end[n] {
+					n = m.dict[n]
+				}
+				for ; n >= 0; n = m.dict[n] {
+					s := units - int(m.units[n])
+					if bestUnit >= 0 && s > bestUnit {
+						break
+					}
+					if b := m.back(line, pos, int(m.units[n])); IsWord(line, b, pos) {
+						bestUnit, start, end = s, b, pos
+						break
+					}
+				}
+			}
+		}
+
+		// a later match cannot start before units-m.units[node]
+		if bestUnit >= 0 && bestUnit < units-int(m.units[node]) {
+			break
+		}
+	}
+	return start, end, bestUnit >= 0
+}
+
+// back returns the offset in line that is units before pos.
+func (m *acMatcher) back(line string, pos, units int) int {
+	if !m.fold {
+		return pos - units
+	}
+	for range units {
+		_, size := utf8.DecodeLastRuneInString(line[:pos])
+		pos -= size
+	}
+	return pos
+}
+
+// whole reports whether line is one of the patterns, for -x.
+func (m *acMatcher) whole(line string) bool {
+	node := int32(0)
+	step := func(b byte) bool {
+		next := m.delta[int(node)*m.width+int(m.classes[b])]
+		// delta also follows failures, which lead to shallower nodes
+		if m.depth[next] != m.depth[node]+1 {
+			return false
+		}
+		node = next
+		return true
+	}
+	var buf [utf8.UTFMax]byte
+	for pos := 0; pos < len(line); {
+		if b := line[pos]; !m.fold || b < utf8.RuneSelf {
+			if m.fold && 'a' <= b && b <= 'z' {
+				b -= 'a' - 'A'
+			}
+			if !step(b) {
+				return false
+			}
+			pos++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(line[pos:])
+		n := utf8.EncodeRune(buf[:], foldRune(r))
+		for _, b := range buf[:n] {
+			if !step(b) {
+				return false
+			}
+		}
+		pos += size
+	}
+	return m.end[node]
+}
+
+// foldRune maps r to the smallest rune of its simple case folding orbit, so
+// runes that (?i) treats as equal map to the same rune.
+func foldRune(r rune) rune {
+	if r < utf8.RuneSelf {
+		if 'a' <= r && r <= 'z' {
+			r -= 'a' - 'A'
+		}
+		return r
+	}
+	least := r
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		least = min(least, f)
+	}
+	return least
+}
+
+func foldString(s string) string {
+	b := make([]byte, 0, len(s))
+	for _, r := range s {
+		b = utf8.AppendRune(b, foldRune(r))
+	}
+	return string(b)
+}
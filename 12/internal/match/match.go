@@ -0,0 +1,167 @@
+package match
+
+import (
+	"grep/internal/config"
+	"regexp"
+	"regexp/syntax"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Matcher finds the matches of a pattern set in a line.
+type Matcher interface {
+	// Find returns the [start, end) byte spans of non-overlapping matches in line,
+	// leftmost first; nil means no match. Unless all is set, it stops at the first one.
+	Find(line string, all bool) [][2]int
+}
+
+// New compiles patterns into a Matcher honouring -F, -i, -w and -x.
+// A line matches if any of the patterns matches it; an empty set matches nothing.
+// Fixed strings are searched with an Aho-Corasick automaton and match
+// leftmost-longest, as in GNU grep; regexps match leftmost-first.
+func New(patterns []string, opt config.Flags) (Matcher, error) {
+	if len(patterns) == 0 {
+		return never{}, nil
+	}
+	if opt.FixedString && !slices.Contains(patterns, "") {
+		return newAC(patterns, opt.IgnoreCase, opt.WordRegexp && !opt.LineRegexp, opt.LineRegexp), nil
+	}
+	alts := make([]string, len(patterns))
+	for i, p := range patterns {
+		if opt.FixedString {
+			p = regexp.QuoteMeta(p)
+		}
+		alts[i] = "(?:" + p + ")"
+	}
+	expr := strings.Join(alts, "|")
+	if opt.LineRegexp {
+		expr = "^(?:" + expr + ")$"
+	}
+	if opt.IgnoreCase {
+		expr = "(?i)" + expr
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	return FromRegexp(re, opt.WordRegexp && !opt.LineRegexp), nil
+}
+
+// FromRegexp wraps a compiled regexp; with word set, matches must also be
+// whole words, i.e. not preceded or followed by a letter, digit or underscore.
+func FromRegexp(re *regexp.Regexp, word bool) Matcher {
+	if re == nil {
+		return never{}
+	}
+	m := &regexpMatcher{re: re}
+	if word {
+		m.word, m.wordAfter = wordRegexps(re)
+	}
+	return m
+}
+
+type regexpMatcher struct {
+	re *regexp.Regexp
+	// for -w: re as group 1 between non-word characters or the ends of the
+	// line, and the same where the line cannot begin, to search after a match
+	word, wordAfter *regexp.Regexp
+}
+
+// nonWord is the class of characters isWordChar rejects.
+const nonWord = `[^\p{L}\p{Nd}_]`
+
+// wordRegexps builds the regexps of regexpMatcher for -w, as GNU grep does.
+// As the context is part of the regexp, the regexp engine itself falls back
+// to a shorter match when a longer one at the same start is not a word.
+func wordRegexps(re *regexp.Regexp) (word, wordAfter *regexp.Regexp) {
+	expr := `(?:^|` + nonWord + `)(` + re.String() + `)(?:` + nonWord + `|$)`
+	word = regexp.MustCompile(expr)
+
+	// searching after a match starts at a slice of the line, where ^ must not match
+	tree, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		panic(err) // expr has just compiled
+	}
+	neverBegin(tree)
+	return word, regexp.MustCompile(tree.String())
+}
+
+// neverBegin makes the assertions of the beginning of text or line in re fail.
+func neverBegin(re *syntax.Regexp) {
+	if re.Op == syntax.OpBeginText || re.Op == syntax.OpBeginLine {
+		re.Op = syntax.OpNoMatch
+	}
+	for _, sub := range re.Sub {
+		neverBegin(sub)
+	}
+}
+
+func (m *regexpMatcher) Find(line string, all bool) [][2]int {
+	if m.word == nil {
+		n := 1
+		if all {
+			n = -1
+		}
+		var spans [][2]int
+		for _, loc := range m.re.FindAllStringIndex(line, n) {
+			spans = append(spans, [2]int{loc[0], loc[1]})
+		}
+		return spans
+	}
+
+	// like GNU grep -w: a match that is not a whole word does not hide a
+	// shorter or a later one
+	var spans [][2]int
+	for pos := 0; pos <= len(line); {
+		// after a match, search from the character before pos, which the
+		// regexp checks to be a non-word one
+		re, from := m.word, 0
+		if pos > 0 {
+			_, size := utf8.DecodeLastRuneInString(line[:pos])
+			re, from = m.wordAfter, pos-size
+		}
+		loc := re.FindStringSubmatchIndex(line[from:])
+		if loc == nil {
+			break
+		}
+		start, end := from+loc[2], from+loc[3]
+		spans = append(spans, [2]int{start, end})
+		if !all {
+			break
+		}
+		if end > pos {
+			pos = end
+			continue
+		}
+		// an empty match: go on after it
+		_, size := utf8.DecodeRuneInString(line[end:])
+		pos = end + max(size, 1)
+	}
+	return spans
+}
+
+// IsWord reports whether line[start:end] is neither preceded nor followed by a word character.
+func IsWord(line string, start, end int) bool {
+	if start > 0 {
+		if r, _ := utf8.DecodeLastRuneInString(line[:start]); isWordChar(r) {
+			return false
+		}
+	}
+	if end < len(line) {
+		if r, _ := utf8.DecodeRuneInString(line[end:]); isWordChar(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func isWordChar(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// never matches nothing.
+type never struct{}
+
+func (never) Find(string, bool) [][2]int { return nil }
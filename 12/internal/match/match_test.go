@@ -0,0 +1,229 @@
+package match
+
+import (
+	"grep/internal/config"
+	"math/rand"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestFixedStrings(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		flags    config.Flags
+		line     string
+		expected [][2]int
+	}{
+		{
+			name:     "leftmost longest",
+			patterns: []string{"foo", "foobar", "oba"},
+			line:     "xfoobarfoo",
+			expected: [][2]int{{1, 7}, {7, 10}},
+		},
+		{
+			name:     "longer match starting earlier wins",
+			patterns: []string{"bcd", "abcde"},
+			line:     "abcdef",
+			expected: [][2]int{{0, 5}},
+		},
+		{
+			name:     "ignore case",
+			patterns: []string{"ПРИвет", "straße"},
+			flags:    config.Flags{IgnoreCase: true},
+			line:     "привет STRAßE",
+			expected: [][2]int{{0, 12}, {13, 20}},
+		},
+		{
+			name:     "ignore case folds the kelvin sign",
+			patterns: []string{"k"},
+			flags:    config.Flags{IgnoreCase: true},
+			line:     "K K",
+			expected: [][2]int{{0, 3}, {4, 5}},
+		},
+		{
+			name:     "word skips a longer non-word match",
+			patterns: []string{"foo", "foo_bar"},
+			flags:    config.Flags{WordRegexp: true},
+			line:     "foo_bar_baz foo",
+			expected: [][2]int{{12, 15}},
+		},
+		{
+			name:     "word takes a shorter pattern at the same start",
+			patterns: []string{"ab", "abc"},
+			flags:    config.Flags{WordRegexp: true},
+			line:     "abcd ab",
+			expected: [][2]int{{5, 7}},
+		},
+		{
+			name:     "line",
+			patterns: []string{"foo", "foobar"},
+			flags:    config.Flags{LineRegexp: true, IgnoreCase: true},
+			line:     "FOOBAR",
+			expected: [][2]int{{0, 6}},
+		},
+		{
+			name:     "line with a longer line",
+			patterns: []string{"foo"},
+			flags:    config.Flags{LineRegexp: true},
+			line:     "foo foo",
+			expected: nil,
+		},
+		{
+			name:     "no match",
+			patterns: []string{"needle"},
+			line:     "haystack",
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.flags.FixedString = true
+			m, err := New(tt.patterns, tt.flags)
+			if err != nil {
+				t.Fatalf("New() returned error: %v", err)
+			}
+
+			spans := m.Find(tt.line, true)
+
+			if !reflect.DeepEqual(spans, tt.expected) {
+				t.Errorf("Find(%q) = %v, expected %v", tt.line, spans, tt.expected)
+			}
+		})
+	}
+}
+
+func TestWordRegexp(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		flags    config.Flags
+		line     string
+		expected [][2]int
+	}{
+		{
+			name:     "anchor is checked against the whole line",
+			patterns: []string{"^."},
+			line:     "ab c",
+			expected: nil,
+		},
+		{
+			name:     "anchor at the start",
+			patterns: []string{"^."},
+			line:     "a bc",
+			expected: [][2]int{{0, 1}},
+		},
+		{
+			name:     "shorter match at the same start",
+			patterns: []string{"a b", "a"},
+			line:     "a bc",
+			expected: [][2]int{{0, 1}},
+		},
+		{
+			name:     "shorter repetition at the same start",
+			patterns: []string{"fo+"},
+			line:     "foo_ foo",
+			expected: [][2]int{{5, 8}},
+		},
+		{
+			name:     "later match after a non-word one",
+			patterns: []string{"foo"},
+			line:     "foobar foo",
+			expected: [][2]int{{7, 10}},
+		},
+		{
+			name:     "adjacent words",
+			patterns: []string{"a"},
+			flags:    config.Flags{IgnoreCase: true},
+			line:     "a A,a",
+			expected: [][2]int{{0, 1}, {2, 3}, {4, 5}},
+		},
+		{
+			name:     "word boundary after a match",
+			patterns: []string{`\bb`},
+			line:     "a b",
+			expected: [][2]int{{2, 3}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.flags.WordRegexp = true
+			m, err := New(tt.patterns, tt.flags)
+			if err != nil {
+				t.Fatalf("New() returned error: %v", err)
+			}
+
+			spans := m.Find(tt.line, true)
+
+			if !reflect.DeepEqual(spans, tt.expected) {
+				t.Errorf("Find(%q) = %v, expected %v", tt.line, spans, tt.expected)
+			}
+		})
+	}
+}
+
+// TestFixedStringsLikeRegexp compares the automaton with a leftmost-longest
+// regexp of the quoted patterns on random lines.
+func TestFixedStringsLikeRegexp(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	alphabet := []rune("abcAB_ ßẞк")
+	word := func(n int) string {
+		var b strings.Builder
+		for range 1 + rng.Intn(n) {
+			b.WriteRune(alphabet[rng.Intn(len(alphabet))])
+		}
+		return b.String()
+	}
+
+	for i := range 2000 {
+		patterns := make([]string, 1+rng.Intn(4))
+		for j := range patterns {
+			patterns[j] = word(3)
+		}
+		flags := config.Flags{
+			FixedString: true,
+			IgnoreCase:  i%2 == 1,
+			WordRegexp:  i%3 == 1,
+			LineRegexp:  i%7 == 1,
+		}
+		line := word(12)
+
+		m, _ := New(patterns, flags)
+		flags.FixedString = false
+		for j, p := range patterns {
+			patterns[j] = regexp.QuoteMeta(p)
+		}
+		re, _ := New(patterns, flags)
+		rm := re.(*regexpMatcher)
+		rm.re.Longest()
+		if rm.word != nil {
+			rm.word.Longest()
+			rm.wordAfter.Longest()
+		}
+
+		got, want := m.Find(line, true), re.Find(line, true)
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("patterns %q, flags %+v: Find(%q) = %v, regexp finds %v", patterns, flags, line, got, want)
+		}
+	}
+}
+
+func TestFixedStringsIgnoreCaseAllocs(t *testing.T) {
+	m, _ := New([]string{"Привет", "world", "ID-0042"}, config.Flags{FixedString: true, IgnoreCase: true})
+	// near misses walk deep into the automaton; only a match allocates its spans
+	line := "Hello, ПРИВЕДИ the WORL D apart, id-0041"
+
+	allocs := testing.AllocsPerRun(100, func() {
+		if m.Find(line, true) != nil {
+			t.Fatalf("Find(%q) matched", line)
+		}
+	})
+
+	if allocs != 0 {
+		t.Errorf("Find() allocates %v times per line, expected none", allocs)
+	}
+}
@@ -1,14 +1,24 @@
 package reader
 
 import (
+	"bufio"
+	"errors"
+	"fmt"
+	"grep/internal/config"
 	"io"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"strings"
 )
 
+// Stdin is the name of standard input in file lists and output prefixes.
+const Stdin = "(standard input)"
+
 // Open determines which file to open.
 func Open(args []string) (io.ReadCloser, error) {
-	if len(args) == 0 {
-		return os.Stdin, nil
+	if len(args) == 0 || args[0] == "-" {
+		return io.NopCloser(os.Stdin), nil
 	}
 	f, err := os.Open(args[0])
 	if err != nil {
@@ -16,3 +26,129 @@ func Open(args []string) (io.ReadCloser, error) {
 	}
 	return f, nil
 }
+
+// OpenFile opens a name returned by Files; Stdin means standard input.
+func OpenFile(name string) (io.ReadCloser, error) {
+	if name == Stdin {
+		return Open(nil)
+	}
+	return Open([]string{name})
+}
+
+// Files expands the operands into the list of files to search, in walk order,
+// and reports whether a directory was walked. No operands mean stdin, or the
+// current directory with -r; "-" means stdin. Walked files are named as GNU
+// grep names them: joined onto the operand as typed, or relative to the
+// current directory without operands. Problems with single operands are
+// passed to warn, and the walk goes on.
+func Files(args []string, opt config.Flags, warn func(error)) (files []string, walked bool) {
+	if len(args) == 0 {
+		if !opt.Recursive {
+			return []string{Stdin}, false
+		}
+		return walk(".", "", opt, warn), true
+	}
+
+	for _, arg := range args {
+		if arg == "-" {
+			files = append(files, Stdin)
+			continue
+		}
+		st, err := os.Stat(arg)
+		if err != nil {
+			warn(named(arg, err))
+			continue
+		}
+		if !st.IsDir() {
+			// named files are searched even if --include/--exclude would skip them in a walk
+			files = append(files, arg)
+			continue
+		}
+		if !opt.Recursive {
+			warn(fmt.Errorf("%s: Is a directory", arg))
+			continue
+		}
+		files = append(files, walk(arg, arg, opt, warn)...)
+		walked = true
+	}
+	return files, walked
+}
+
+// walk lists the files under root, naming them after operand; an empty
+// operand names them relative to root.
+func walk(root, operand string, opt config.Flags, warn func(error)) []string {
+	var (
+		files  []string
+		ignore gitIgnore
+	)
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			warn(named(walkedName(root, operand, p), err))
+			if d != nil && d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			if p != root && (matchAny(opt.ExcludeDir, p) || opt.GitIgnore && (d.Name() == ".git" || ignore.ignored(p, true))) {
+				return filepath.SkipDir
+			}
+			if opt.GitIgnore {
+				if err := ignore.load(p); err != nil {
+					warn(err)
+				}
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		if len(opt.Include) > 0 && !matchAny(opt.Include, p) {
+			return nil
+		}
+		if matchAny(opt.Exclude, p) || opt.GitIgnore && ignore.ignored(p, false) {
+			return nil
+		}
+		files = append(files, walkedName(root, operand, p))
+		return nil
+	})
+	if err != nil && !errors.Is(err, filepath.SkipDir) {
+		warn(err)
+	}
+	return files
+}
+
+// walkedName names the file p found under root after operand, with its
+// trailing separators dropped.
+func walkedName(root, operand, p string) string {
+	rel, err := filepath.Rel(root, p)
+	switch {
+	case err != nil || operand == "":
+		return p
+	case rel == ".":
+		return operand
+	}
+	sep := string(filepath.Separator)
+	return strings.TrimRight(operand, sep) + sep + rel
+}
+
+// named reports err as "name: cause", like GNU grep, dropping the operation
+// and path of a *fs.PathError.
+func named(name string, err error) error {
+	var pe *fs.PathError
+	if errors.As(err, &pe) {
+		err = pe.Err
+	}
+	return fmt.Errorf("%s: %w", name, err)
+}
+
+// Lines reads all lines of r, e.g. the patterns of -f.
+func Lines(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 4096), 10*1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines, scanner.Err()
+}
@@ -0,0 +1,70 @@
+package reader
+
+import (
+	"grep/internal/config"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.txt":        "x\n",
+		"d/b.txt":      "x\n",
+		"d/e/c.txt":    "x\n",
+		"g/.gitignore": "secret.txt\n",
+		"g/kept.txt":   "x\n",
+		"g/secret.txt": "x\n",
+	}
+	for name, data := range files {
+		name = filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(dir)
+
+	tests := []struct {
+		name      string
+		args      []string
+		gitIgnore bool
+		expected  []string
+		walked    bool
+		warning   string
+	}{
+		{name: "no operands", expected: []string{"a.txt", "d/b.txt", "d/e/c.txt", "g/.gitignore", "g/kept.txt", "g/secret.txt"}, walked: true},
+		{name: "dot", args: []string{"."}, expected: []string{"./a.txt", "./d/b.txt", "./d/e/c.txt", "./g/.gitignore", "./g/kept.txt", "./g/secret.txt"}, walked: true},
+		{name: "dot slash directory", args: []string{"./d"}, expected: []string{"./d/b.txt", "./d/e/c.txt"}, walked: true},
+		{name: "trailing slashes", args: []string{"d//"}, expected: []string{"d/b.txt", "d/e/c.txt"}, walked: true},
+		{name: "single file", args: []string{"a.txt"}, expected: []string{"a.txt"}},
+		{name: "files only", args: []string{"a.txt", "d/b.txt"}, expected: []string{"a.txt", "d/b.txt"}},
+		{name: "directory with one file", args: []string{"d/e"}, expected: []string{"d/e/c.txt"}, walked: true},
+		{name: "gitignore", args: []string{"g"}, gitIgnore: true, expected: []string{"g/.gitignore", "g/kept.txt"}, walked: true},
+		{name: "gitignore under dot slash", args: []string{"./g"}, gitIgnore: true, expected: []string{"./g/.gitignore", "./g/kept.txt"}, walked: true},
+		{name: "gitignore under trailing slash", args: []string{"g/"}, gitIgnore: true, expected: []string{"g/.gitignore", "g/kept.txt"}, walked: true},
+		{name: "gitignore without operands", gitIgnore: true, expected: []string{"a.txt", "d/b.txt", "d/e/c.txt", "g/.gitignore", "g/kept.txt"}, walked: true},
+		{name: "missing file", args: []string{"a.txt", "missing"}, expected: []string{"a.txt"}, warning: "missing: no such file or directory"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var warnings []string
+			files, walked := Files(tt.args, config.Flags{Recursive: true, GitIgnore: tt.gitIgnore}, func(err error) {
+				warnings = append(warnings, err.Error())
+			})
+
+			if !reflect.DeepEqual(files, tt.expected) || walked != tt.walked {
+				t.Errorf("Files(%q) = %q, %v, expected %q, %v", tt.args, files, walked, tt.expected, tt.walked)
+			}
+			if warning := strings.Join(warnings, "\n"); warning != tt.warning {
+				t.Errorf("Files(%q) warned %q, expected %q", tt.args, warning, tt.warning)
+			}
+		})
+	}
+}
@@ -1,62 +1,197 @@
 package run
 
 import (
+	"bytes"
 	"fmt"
+	"grep/internal/color"
 	"grep/internal/config"
+	"grep/internal/match"
 	"grep/internal/reader"
 	"io"
 	"os"
-	"regexp"
+	"runtime"
 )
 
 // StreamProcesser can process stream.
 type StreamProcesser interface {
-	ProcessStream(r io.Reader, pattern string, re *regexp.Regexp) (int, error)
+	// Process searches r and writes the output to w, prefixing lines with name unless it is empty.
+	Process(r io.Reader, w io.Writer, name string, m match.Matcher) (int, error)
 }
 
-func compileRegexp(pattern string, ignoreCase bool) (*regexp.Regexp, error) {
-	if ignoreCase {
-		pattern = "(?i)" + pattern
+// patterns collects -e patterns and the lines of -f files; without either
+// the first operand is the pattern. Returns the patterns and the file operands.
+func patterns(args []string, opt config.Flags) ([]string, []string, error) {
+	if len(opt.Patterns) == 0 && len(opt.PatternFiles) == 0 {
+		if len(args) == 0 {
+			return nil, nil, fmt.Errorf("no pattern given")
+		}
+		return args[:1], args[1:], nil
+	}
+	pats := append([]string(nil), opt.Patterns...)
+	for _, name := range opt.PatternFiles {
+		r, err := reader.OpenFile(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		lines, err := reader.Lines(r)
+		_ = r.Close()
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", name, err)
+		}
+		pats = append(pats, lines...)
 	}
-	return regexp.Compile(pattern)
+	return pats, args, nil
 }
 
 // Run runs the CLI tool.
 func Run(args []string, opt config.Flags, sp StreamProcesser) (err error) {
-	pattern := args[0]
-	r, err := reader.Open(args[1:])
-	defer func() {
-		err = r.Close()
-	}()
-
+	pats, args, err := patterns(args, opt)
 	if err != nil {
-		return err
+		fmt.Fprintln(os.Stderr, "grep:", err)
+		os.Exit(2)
 	}
-
-	var re *regexp.Regexp
-	if !opt.FixedString {
-		re, err = compileRegexp(args[0], opt.IgnoreCase)
-		if err != nil {
-			println("regex compile error:", err.Error())
-			os.Exit(2)
-		}
+	m, err := match.New(pats, opt)
+	if err != nil {
+		println("regex compile error:", err.Error())
+		os.Exit(2)
 	}
 
-	count, err := sp.ProcessStream(r, pattern, re)
-	if err != nil {
-		println("process error:", err.Error())
-		os.Exit(3)
+	failed := false
+	warn := func(err error) {
+		fmt.Fprintln(os.Stderr, "grep:", err)
+		failed = true
 	}
+	files, walked := reader.Files(args, opt, warn)
 
-	if opt.OnlyCount {
-		fmt.Println(count)
+	// like GNU grep, by the operands given, not by the files that exist
+	withName := walked || len(args) > 1
+	if opt.WithFilename {
+		withName = true
+	}
+	if opt.NoFilename {
+		withName = false
 	}
 
-	foundAny := count > 0
+	s := searcher{opt: opt, sp: sp, m: m, withName: withName}
+	count := s.searchAll(files, os.Stdout, warn)
 
-	if foundAny {
+	if failed {
+		os.Exit(2)
+	}
+	if count > 0 {
 		os.Exit(0)
 	}
 	os.Exit(1)
 	return nil
 }
+
+// searcher searches files one by one with the same pattern.
+type searcher struct {
+	opt      config.Flags
+	sp       StreamProcesser
+	m        match.Matcher
+	withName bool
+}
+
+// result is the buffered output of one file.
+type result struct {
+	out   bytes.Buffer
+	count int
+	err   error
+}
+
+// searchAll searches files concurrently but writes their output to w in the
+// order of files, so the output does not depend on scheduling.
+// Returns the total number of matching lines.
+func (s *searcher) searchAll(files []string, w io.Writer, warn func(error)) int {
+	if len(files) == 1 {
+		// stream a single input as it is read, e.g. from tail -f
+		count, err := s.search(files[0], w)
+		if err != nil {
+			warn(err)
+		}
+		return count
+	}
+
+	workers := min(runtime.NumCPU(), len(files))
+	// files searched but not yet printed are limited, so is the buffered output
+	window := make(chan struct{}, 2*workers)
+	results := make([]chan *result, len(files))
+	for i := range results {
+		results[i] = make(chan *result, 1)
+	}
+	jobs := make(chan int)
+	go func() {
+		defer close(jobs)
+		for i := range files {
+			window <- struct{}{}
+			jobs <- i
+		}
+	}()
+	for range workers {
+		go func() {
+			for i := range jobs {
+				res := &result{}
+				res.count, res.err = s.search(files[i], &res.out)
+				results[i] <- res
+			}
+		}()
+	}
+
+	// groups of different files are separated too
+	groupSep, hasGroupSep := s.opt.Separator()
+	printed := false
+	total := 0
+	for i := range files {
+		res := <-results[i]
+		if res.out.Len() > 0 {
+			if hasGroupSep && printed {
+				colors := s.palette()
+				fmt.Fprintln(w, colors.Paint(colors.Separator, groupSep))
+			}
+			printed = true
+		}
+		_, _ = res.out.WriteTo(w)
+		if res.err != nil {
+			warn(res.err)
+		}
+		total += res.count
+		<-window
+	}
+	return total
+}
+
+// search searches one file, writing its lines, or its count with -c, to w.
+func (s *searcher) search(name string, w io.Writer) (int, error) {
+	r, err := reader.OpenFile(name)
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+
+	prefix := ""
+	if s.withName {
+		prefix = name
+	}
+	count, err := s.sp.Process(r, w, prefix, s.m)
+	if err != nil {
+		return count, fmt.Errorf("%s: %w", name, err)
+	}
+	if s.opt.OnlyCount {
+		if prefix != "" {
+			colors := s.palette()
+			fmt.Fprintf(w, "%s%s%d\n", colors.Paint(colors.FileName, prefix), colors.Paint(colors.Separator, ":"), count)
+		} else {
+			fmt.Fprintln(w, count)
+		}
+	}
+	return count, nil
+}
+
+// palette returns the colors of the output; the zero palette paints nothing.
+func (s *searcher) palette() *color.Palette {
+	if s.opt.Colors == nil {
+		return &color.Palette{}
+	}
+	return s.opt.Colors
+}
@@ -3,9 +3,13 @@ package stream
 import (
 	"bufio"
 	"fmt"
+	"grep/internal/color"
 	"grep/internal/config"
+	"grep/internal/match"
 	"io"
+	"os"
 	"regexp"
+	"strconv"
 	"strings"
 )
 
@@ -26,23 +30,73 @@ type prevLine struct {
 
 const maxToken = 10 * 1024 * 1024
 
-// ProcessStream processes stream according to the options
+// ProcessStream processes stream according to the options, printing to stdout.
+// The pattern is a fixed string with -F, otherwise re is used.
 func (p *Processor) ProcessStream(r io.Reader, pattern string, re *regexp.Regexp) (int, error) {
+	return p.Process(r, os.Stdout, "", p.matcher(pattern, re))
+}
+
+// matcher builds a Matcher for a single pattern or regexp.
+func (p *Processor) matcher(pattern string, re *regexp.Regexp) match.Matcher {
+	if p.opt.FixedString {
+		m, err := match.New([]string{pattern}, *p.opt)
+		if err == nil {
+			return m
+		}
+	}
+	return match.FromRegexp(re, p.opt.WordRegexp)
+}
+
+// Process is ProcessStream writing to w with a pattern set; a non-empty name
+// prefixes every line as "name:", or "name-" for context lines.
+func (p *Processor) Process(r io.Reader, w io.Writer, name string, m match.Matcher) (int, error) {
+	// -m 0 selects nothing, so the input is not even read, as in GNU grep
+	if p.opt.HasMaxCount && p.opt.MaxCount == 0 {
+		return 0, nil
+	}
+
 	scanner := bufio.NewScanner(r)
 	scanner.Buffer(make([]byte, 4096), maxToken)
 
-	prev := make([]prevLine, 0, p.opt.Before+1)
+	before, after := p.opt.Before, p.opt.After
+
+	prev := make([]prevLine, 0, before+1)
 	idx := 0
 	trailing := 0
 	matchCount := 0
 	last := 0
 
-	_print := func(num int, text string) {
+	colors := p.opt.Colors
+	if colors == nil {
+		colors = &color.Palette{} // paints nothing
+	}
+	groupSep, hasGroupSep := p.opt.Separator()
+	_print := func(num int, text string, selected bool, spans [][2]int) {
+		if hasGroupSep && last > 0 && num > last+1 {
+			fmt.Fprintln(w, colors.Paint(colors.Separator, groupSep))
+		}
+		// -o prints no context lines, but as in GNU grep they still join
+		// matches into one group
+		if p.opt.OnlyMatching && !selected {
+			last = num
+			return
+		}
+		// "name:N:" for selected lines, "name-N-" for context lines
+		sep := "-"
+		if selected {
+			sep = ":"
+		}
 		prefix := ""
+		if name != "" {
+			prefix = colors.Paint(colors.FileName, name) + colors.Paint(colors.Separator, sep)
+		}
 		if p.opt.PrintNumbers {
-			prefix = fmt.Sprintf("%d\t", num)
+			prefix += colors.Paint(colors.LineNumber, strconv.Itoa(num)) + colors.Paint(colors.Separator, sep)
+		}
+		if p.opt.Colors != nil {
+			text = highlight(colors, text, selected != (p.opt.Invert && colors.Reverse), spans)
 		}
-		fmt.Println(prefix + text)
+		fmt.Fprintln(w, prefix+text)
 		last = num
 	}
 
@@ -50,36 +104,49 @@ func (p *Processor) ProcessStream(r io.Reader, pattern string, re *regexp.Regexp
 		line := scanner.Text()
 		idx++
 
-		match := p.isMatch(line, pattern, re)
+		spans := p.isMatch(line, m)
+		match := spans != nil
 		if p.opt.Invert {
 			match = !match
 		}
+		// past -m NUM the rest is only read for trailing context
+		limited := p.opt.HasMaxCount && matchCount >= p.opt.MaxCount
 
-		if match {
+		if match && !limited {
 			matchCount++
 			if !p.opt.OnlyCount {
 				for _, pl := range prev {
 					if pl.num > last {
-						_print(pl.num, pl.text)
+						_print(pl.num, pl.text, false, p.contextSpans(pl.text, m))
 					}
 				}
 
-				_print(idx, line)
+				if p.opt.OnlyMatching {
+					for _, s := range spans {
+						if s[1] > s[0] {
+							_print(idx, line[s[0]:s[1]], true, [][2]int{{0, s[1] - s[0]}})
+						}
+					}
+				} else {
+					_print(idx, line, true, spans)
+				}
 			}
 
-			trailing = max(trailing, p.opt.After)
+			trailing = max(trailing, after)
 		} else {
 			if trailing > 0 {
 				if !p.opt.OnlyCount {
-					_print(idx, line)
+					_print(idx, line, false, spans)
 				}
 				trailing--
+			} else if limited {
+				break
 			}
 		}
 
-		if p.opt.Before > 0 {
+		if before > 0 {
 			prev = append(prev, prevLine{idx, line})
-			if len(prev) > p.opt.Before {
+			if len(prev) > before {
 				prev = prev[1:]
 			}
 		} else {
@@ -95,15 +162,35 @@ func (p *Processor) ProcessStream(r io.Reader, pattern string, re *regexp.Regexp
 
 }
 
-func (p *Processor) isMatch(line, pattern string, re *regexp.Regexp) bool {
-	if p.opt.FixedString {
-		if p.opt.IgnoreCase {
-			return strings.Contains(strings.ToLower(line), strings.ToLower(pattern))
-		}
-		return strings.Contains(line, pattern)
+// contextSpans finds matches to highlight in a context line. Only with -v
+// can a context line match, and only colored output needs the spans.
+func (p *Processor) contextSpans(line string, m match.Matcher) [][2]int {
+	if p.opt.Colors == nil || !p.opt.Invert {
+		return nil
+	}
+	return m.Find(line, true)
+}
+
+// highlight paints the matched spans of a line and the text around them,
+// using ms and sl for selected lines, mc and cx for context lines.
+func highlight(colors *color.Palette, line string, selected bool, spans [][2]int) string {
+	lineColor, matchColor := colors.Context, colors.MatchContext
+	if selected {
+		lineColor, matchColor = colors.Selected, colors.MatchSelected
 	}
-	if re == nil {
-		return false
+	var b strings.Builder
+	pos := 0
+	for _, s := range spans {
+		b.WriteString(colors.Paint(lineColor, line[pos:s[0]]))
+		b.WriteString(colors.Paint(matchColor, line[s[0]:s[1]]))
+		pos = s[1]
 	}
-	return re.FindStringIndex(line) != nil
+	b.WriteString(colors.Paint(lineColor, line[pos:]))
+	return b.String()
+}
+
+// isMatch returns the spans of matches in line, all of them for -o; nil means no match.
+func (p *Processor) isMatch(line string, m match.Matcher) [][2]int {
+	// colors highlight every match, -o prints every match
+	return m.Find(line, (p.opt.OnlyMatching || p.opt.Colors != nil) && !p.opt.Invert)
 }
@@ -1,7 +1,11 @@
 package stream
 
 import (
+	"fmt"
+	"grep/internal/color"
 	"grep/internal/config"
+	"grep/internal/match"
+	"io"
 	"regexp"
 	"strings"
 	"testing"
@@ -99,7 +103,7 @@ func TestProcessorIsMatch(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			processor := NewProcessor(&tt.flags)
-			result := processor.isMatch(tt.line, tt.pattern, tt.regex)
+			result := processor.isMatch(tt.line, processor.matcher(tt.pattern, tt.regex)) != nil
 			if result != tt.expected {
 				t.Errorf("isMatch() = %v, expected %v", result, tt.expected)
 			}
@@ -230,6 +234,131 @@ func TestProcessStreamContextLines(t *testing.T) {
 	}
 }
 
+func TestProcessContextGroups(t *testing.T) {
+	input := "a\nmatch\nb\nc\nd\nmatch\nmatch\ne\nf\nmatch"
+
+	tests := []struct {
+		name     string
+		flags    config.Flags
+		expected string
+		count    int
+	}{
+		{
+			name:     "separator between groups",
+			flags:    config.Flags{Before: 1, Context: true, GroupSeparator: "--", PrintNumbers: true},
+			expected: "1-a\n2:match\n--\n5-d\n6:match\n7:match\n--\n9-f\n10:match\n",
+			count:    4,
+		},
+		{
+			name:     "adjacent groups are merged",
+			flags:    config.Flags{After: 1, Before: 1, Context: true, GroupSeparator: "--"},
+			expected: "a\nmatch\nb\n--\nd\nmatch\nmatch\ne\nf\nmatch\n",
+			count:    4,
+		},
+		{
+			name:     "custom separator",
+			flags:    config.Flags{After: 1, Context: true, GroupSeparator: "=="},
+			expected: "match\nb\n==\nmatch\nmatch\ne\n==\nmatch\n",
+			count:    4,
+		},
+		{
+			name:     "no separator",
+			flags:    config.Flags{After: 1, Context: true, GroupSeparator: "--", NoGroupSeparator: true},
+			expected: "match\nb\nmatch\nmatch\ne\nmatch\n",
+			count:    4,
+		},
+		{
+			name:     "no separator without context",
+			flags:    config.Flags{GroupSeparator: "--"},
+			expected: "match\nmatch\nmatch\nmatch\n",
+			count:    4,
+		},
+		{
+			name:     "separator with zero lines of context",
+			flags:    config.Flags{Context: true, GroupSeparator: "--", PrintNumbers: true},
+			expected: "2:match\n--\n6:match\n7:match\n--\n10:match\n",
+			count:    4,
+		},
+		{
+			name:     "only matching groups by unprinted context",
+			flags:    config.Flags{After: 1, Before: 1, Context: true, GroupSeparator: "--", OnlyMatching: true, PrintNumbers: true},
+			expected: "2:match\n--\n6:match\n7:match\n10:match\n",
+			count:    4,
+		},
+		{
+			name:     "only matching with zero lines of context",
+			flags:    config.Flags{Context: true, GroupSeparator: "--", OnlyMatching: true},
+			expected: "match\n--\nmatch\nmatch\n--\nmatch\n",
+			count:    4,
+		},
+		{
+			name:     "max count keeps trailing context",
+			flags:    config.Flags{HasMaxCount: true, MaxCount: 2, After: 2, Context: true, GroupSeparator: "--", PrintNumbers: true},
+			expected: "2:match\n3-b\n4-c\n--\n6:match\n7-match\n8-e\n",
+			count:    2,
+		},
+		{
+			name:     "max count with count",
+			flags:    config.Flags{HasMaxCount: true, MaxCount: 3, OnlyCount: true},
+			expected: "",
+			count:    3,
+		},
+		{
+			name:     "zero max count selects nothing",
+			flags:    config.Flags{HasMaxCount: true, After: 2, Context: true, GroupSeparator: "--"},
+			expected: "",
+			count:    0,
+		},
+		{
+			name:     "zero max count with count",
+			flags:    config.Flags{HasMaxCount: true, OnlyCount: true},
+			expected: "",
+			count:    0,
+		},
+		{
+			name:     "max count with invert",
+			flags:    config.Flags{HasMaxCount: true, MaxCount: 2, Invert: true},
+			expected: "a\nb\n",
+			count:    2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.flags.FixedString = true
+			processor := NewProcessor(&tt.flags)
+			var out strings.Builder
+
+			count, err := processor.Process(strings.NewReader(input), &out, "", processor.matcher("match", nil))
+
+			if err != nil {
+				t.Errorf("Process() returned error: %v", err)
+			}
+			if count != tt.count {
+				t.Errorf("Process() count = %d, expected %d", count, tt.count)
+			}
+			if out.String() != tt.expected {
+				t.Errorf("Process() output = %q, expected %q", out.String(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestProcessContextPrefixes(t *testing.T) {
+	flags := config.Flags{FixedString: true, PrintNumbers: true, After: 1, GroupSeparator: "--"}
+	processor := NewProcessor(&flags)
+	var out strings.Builder
+
+	_, err := processor.Process(strings.NewReader("match\nafter\n"), &out, "f.txt", processor.matcher("match", nil))
+
+	if err != nil {
+		t.Errorf("Process() returned error: %v", err)
+	}
+	if expected := "f.txt:1:match\nf.txt-2-after\n"; out.String() != expected {
+		t.Errorf("Process() output = %q, expected %q", out.String(), expected)
+	}
+}
+
 func TestProcessStreamOnlyCount(t *testing.T) {
 	input := "test\nline\ntest\nother"
 	flags := config.Flags{
@@ -373,7 +502,158 @@ func TestProcessStreamContextOverlap(t *testing.T) {
 	}
 }
 
+func TestProcessFilenamePrefix(t *testing.T) {
+	flags := config.Flags{FixedString: true, PrintNumbers: true}
+	processor := NewProcessor(&flags)
+	var out strings.Builder
+
+	count, err := processor.Process(strings.NewReader("a\nmatch\n"), &out, "file.txt", processor.matcher("match", nil))
+
+	if err != nil {
+		t.Errorf("Process() returned error: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Process() count = %d, expected 1", count)
+	}
+	if expected := "file.txt:2:match\n"; out.String() != expected {
+		t.Errorf("Process() output = %q, expected %q", out.String(), expected)
+	}
+}
+
+func TestProcessPatternSet(t *testing.T) {
+	input := "foo bar foobar\nbaz qux\nfoo\nFOO_x"
+
+	tests := []struct {
+		name     string
+		flags    config.Flags
+		patterns []string
+		expected string
+		count    int
+	}{
+		{
+			name:     "several patterns",
+			flags:    config.Flags{},
+			patterns: []string{"qux", "^foo$"},
+			expected: "baz qux\nfoo\n",
+			count:    2,
+		},
+		{
+			name:     "only matching",
+			flags:    config.Flags{OnlyMatching: true},
+			patterns: []string{"foo", "qux"},
+			expected: "foo\nfoo\nqux\nfoo\n",
+			count:    3,
+		},
+		{
+			name:     "word regexp skips foobar and FOO_x",
+			flags:    config.Flags{WordRegexp: true, OnlyMatching: true, IgnoreCase: true, PrintNumbers: true},
+			patterns: []string{"foo"},
+			expected: "1:foo\n3:foo\n",
+			count:    2,
+		},
+		{
+			name:     "line regexp with fixed strings",
+			flags:    config.Flags{LineRegexp: true, FixedString: true},
+			patterns: []string{"foo", "baz"},
+			expected: "foo\n",
+			count:    1,
+		},
+		{
+			name:     "fixed strings are not regexps",
+			flags:    config.Flags{FixedString: true, OnlyMatching: true},
+			patterns: []string{"o b", "."},
+			expected: "o b\n",
+			count:    1,
+		},
+		{
+			name:     "empty pattern set matches nothing",
+			flags:    config.Flags{},
+			patterns: nil,
+			expected: "",
+			count:    0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := match.New(tt.patterns, tt.flags)
+			if err != nil {
+				t.Fatalf("match.New() returned error: %v", err)
+			}
+			var out strings.Builder
+			count, err := NewProcessor(&tt.flags).Process(strings.NewReader(input), &out, "", m)
+
+			if err != nil {
+				t.Errorf("Process() returned error: %v", err)
+			}
+			if count != tt.count {
+				t.Errorf("Process() count = %d, expected %d", count, tt.count)
+			}
+			if out.String() != tt.expected {
+				t.Errorf("Process() output = %q, expected %q", out.String(), tt.expected)
+			}
+		})
+	}
+}
+
 // Benchmark tests
+func TestProcessColors(t *testing.T) {
+	custom := color.Parse("ms=04;32:fn=34:ne")
+	tests := []struct {
+		name     string
+		flags    config.Flags
+		colors   *color.Palette
+		input    string
+		expected string
+	}{
+		{
+			name:   "default palette",
+			flags:  config.Flags{FixedString: true, PrintNumbers: true},
+			colors: &color.Default,
+			input:  "a foo b foo\n",
+			expected: "\x1b[35m\x1b[Kf.txt\x1b[m\x1b[K\x1b[36m\x1b[K:\x1b[m\x1b[K\x1b[32m\x1b[K1\x1b[m\x1b[K\x1b[36m\x1b[K:\x1b[m\x1b[K" +
+				"a \x1b[01;31m\x1b[Kfoo\x1b[m\x1b[K b \x1b[01;31m\x1b[Kfoo\x1b[m\x1b[K\n",
+		},
+		{
+			name:     "GREP_COLORS without erase",
+			flags:    config.Flags{FixedString: true},
+			colors:   &custom,
+			input:    "foo\n",
+			expected: "\x1b[34mf.txt\x1b[m\x1b[36m:\x1b[m\x1b[04;32mfoo\x1b[m\n",
+		},
+		{
+			name:     "only matching",
+			flags:    config.Flags{FixedString: true, OnlyMatching: true},
+			colors:   &custom,
+			input:    "xfoox\n",
+			expected: "\x1b[34mf.txt\x1b[m\x1b[36m:\x1b[m\x1b[04;32mfoo\x1b[m\n",
+		},
+		{
+			name:     "no palette",
+			flags:    config.Flags{FixedString: true},
+			input:    "foo\n",
+			expected: "f.txt:foo\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.flags.Colors = tt.colors
+			processor := NewProcessor(&tt.flags)
+			var out strings.Builder
+
+			_, err := processor.Process(strings.NewReader(tt.input), &out, "f.txt", processor.matcher("foo", nil))
+
+			if err != nil {
+				t.Errorf("Process() returned error: %v", err)
+			}
+			if out.String() != tt.expected {
+				t.Errorf("Process() output = %q, expected %q", out.String(), tt.expected)
+			}
+		})
+	}
+}
+
 func BenchmarkProcessStreamFixedString(b *testing.B) {
 	input := strings.Repeat("line without match\n", 1000) +
 		strings.Repeat("line with test match\n", 100)
@@ -400,3 +680,47 @@ func BenchmarkProcessStreamRegex(b *testing.B) {
 		_, _ = processor.ProcessStream(reader, "", regex)
 	}
 }
+
+// BenchmarkProcessPatternSet searches a large input for thousands of IDs, as
+// grep -F -f ids.txt does, and compares the automaton with a regexp.
+func BenchmarkProcessPatternSet(b *testing.B) {
+	ids := make([]string, 5000)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("id-%06d", i*7)
+	}
+	var input strings.Builder
+	for i := range 20000 {
+		fmt.Fprintf(&input, "2025-01-02 12:00:00 INFO request served for user ID-%06d in %dms\n", i*3, i%100)
+	}
+	quoted := make([]string, len(ids))
+	for i, id := range ids {
+		quoted[i] = regexp.QuoteMeta(id)
+	}
+
+	benchmarks := []struct {
+		name     string
+		flags    config.Flags
+		patterns []string
+	}{
+		{name: "fixed", flags: config.Flags{FixedString: true}, patterns: ids},
+		{name: "fixed ignore case", flags: config.Flags{FixedString: true, IgnoreCase: true}, patterns: ids},
+		{name: "fixed word", flags: config.Flags{FixedString: true, WordRegexp: true, IgnoreCase: true}, patterns: ids},
+		{name: "regexp ignore case", flags: config.Flags{IgnoreCase: true}, patterns: quoted},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			m, err := match.New(bm.patterns, bm.flags)
+			if err != nil {
+				b.Fatal(err)
+			}
+			processor := NewProcessor(&bm.flags)
+			b.SetBytes(int64(input.Len()))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_, _ = processor.Process(strings.NewReader(input.String()), io.Discard, "", m)
+			}
+		})
+	}
+}